@@ -4,11 +4,20 @@ import (
 	"context"
 	"task_manager/internal/config"
 	"task_manager/internal/database"
+	"task_manager/internal/events"
 	"task_manager/internal/handlers"
 	"task_manager/internal/middleware"
+	"task_manager/internal/models"
+	"task_manager/internal/notifier"
+	"task_manager/internal/repositories"
+	"task_manager/internal/scheduler"
+	"task_manager/internal/storage"
+	"task_manager/internal/webhooks"
+	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/encryptcookie"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -19,6 +28,21 @@ var client, err = database.Connect()
 
 var userCollection *mongo.Collection = client.Database.Collection("users")
 var taskCollection *mongo.Collection = client.Database.Collection("tasks")
+var labelCollection *mongo.Collection = client.Database.Collection("labels")
+var projectCollection *mongo.Collection = client.Database.Collection("projects")
+var commentCollection *mongo.Collection = client.Database.Collection("comments")
+var attachmentCollection *mongo.Collection = client.Database.Collection("attachments")
+var jobCollection *mongo.Collection = client.Database.Collection("jobs")
+var notificationCollection *mongo.Collection = client.Database.Collection("notifications")
+var webhookCollection *mongo.Collection = client.Database.Collection("webhooks")
+var deliveryCollection *mongo.Collection = client.Database.Collection("webhook_deliveries")
+var workspaceCollection *mongo.Collection = client.Database.Collection("workspaces")
+var inviteCollection *mongo.Collection = client.Database.Collection("workspace_invites")
+var activityCollection *mongo.Collection = client.Database.Collection(repositories.ActivityCollectionName)
+var versionCollection *mongo.Collection = client.Database.Collection(repositories.VersionCollectionName)
+var undoCollection *mongo.Collection = client.Database.Collection("undo_actions")
+var templateCollection *mongo.Collection = client.Database.Collection("task_templates")
+var timeCollection *mongo.Collection = client.Database.Collection("time_entries")
 
 func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
@@ -26,9 +50,89 @@ func main() {
 
 	defer client.Disconnect(ctx)
 
+	if err := client.RequireReplicaSet(ctx); err != nil {
+		log.Fatalf("Unsupported database deployment: %v", err)
+	}
+
+	blobStore, err := storage.NewBlobStore(cfg, client.Database)
+	if err != nil {
+		log.Fatalf("Failed to initialize blob storage: %v", err)
+	}
+
+	workspaces := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+	if err := workspaces.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create workspace indexes: %v", err)
+	}
+	if err := workspaces.MigrateLegacyData(ctx, taskCollection, projectCollection, labelCollection, webhookCollection); err != nil {
+		log.Fatalf("Failed to migrate data to workspaces: %v", err)
+	}
+
+	if err := repositories.NewUserRepository(userCollection).EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create user indexes: %v", err)
+	}
+	if err := repositories.NewTaskRepository(taskCollection).EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create task indexes: %v", err)
+	}
+	if err := repositories.NewLabelRepository(labelCollection, taskCollection).EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create label indexes: %v", err)
+	}
+	if err := repositories.NewActivityRepository(activityCollection).EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create activity indexes: %v", err)
+	}
+	if err := repositories.NewVersionRepository(versionCollection).EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create task version indexes: %v", err)
+	}
+	if err := repositories.NewUndoRepository(undoCollection).EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create undo indexes: %v", err)
+	}
+	if err := repositories.NewTimeEntryRepository(timeCollection, taskCollection).EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create time entry indexes: %v", err)
+	}
+
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Изменения задач публикуют обработчики; при нескольких экземплярах
+	// приложения источником событий служит change stream коллекции задач
+	bus := events.NewBus(cfg.EventHistory)
+	var publisher events.Publisher = bus
+	if cfg.EventSource == "changestream" {
+		publisher = events.Discard
+		go func() {
+			if err := events.WatchTasks(bgCtx, taskCollection, bus); err != nil && err != context.Canceled {
+				log.Errorf("Task change stream stopped: %v", err)
+			}
+		}()
+	}
+
+	email := notifier.NewEmailSender(cfg)
+	notifications := notifier.NewDispatcher(userCollection, notifier.NewInAppNotifier(notificationCollection), email)
+
+	sched := scheduler.New(repositories.NewJobRepository(jobCollection), cfg.SchedulerInterval, cfg.SchedulerLease)
+	scheduler.RegisterReminders(sched, taskCollection, notifications, cfg.ReminderOffsets)
+	sched.Every("purge_attachments", time.Hour, func(ctx context.Context, job *models.Job) error {
+		return handlers.PurgeOrphanedAttachments(attachmentCollection, taskCollection, blobStore, ctx)
+	})
+	sched.Every("empty_trash", time.Hour, func(ctx context.Context, job *models.Job) error {
+		return handlers.EmptyExpiredTrash(taskCollection, commentCollection, attachmentCollection, blobStore, cfg.TrashRetention, ctx)
+	})
+	if err := repositories.NewWebhookRepository(webhookCollection, deliveryCollection).EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create webhook indexes: %v", err)
+	}
+	webhookDispatcher := webhooks.Register(sched, webhookCollection, deliveryCollection,
+		webhooks.NewHTTPClient(cfg.WebhookTimeout, cfg.WebhookAllowLocal), cfg.WebhookMaxAttempts, cfg.WebhookDisableAfter)
+	bus.Listen(webhookDispatcher.HandleEvent)
+	go func() {
+		if err := sched.Run(bgCtx); err != nil && err != context.Canceled {
+			log.Errorf("Scheduler stopped: %v", err)
+		}
+	}()
+
 	app := fiber.New(fiber.Config{
 		JSONEncoder: json.Marshal,
 		JSONDecoder: json.Unmarshal,
+		// Запас сверх лимита файла на заголовки multipart
+		BodyLimit: cfg.MaxUploadSize + 1024*1024,
 	})
 
 	app.Use(cors.New(cors.Config{
@@ -48,13 +152,135 @@ func main() {
 	api.Post("/Register", handlers.Register(userCollection))
 	api.Post("/Login", handlers.Login(userCollection))
 	api.Post("/Logout", handlers.Logout)
+	// Календарные приложения авторизуются токеном в ссылке
+	api.Get("/calendar/:token.ics", handlers.CalendarFeed(userCollection, taskCollection, workspaceCollection, inviteCollection))
 
 	api.Use(middleware.AuthMiddleware(userCollection))
-	task := api.Group("/task")
-	task.Post("/create", handlers.CreateTask(taskCollection))
-	task.Get("/get", handlers.GetTasks(taskCollection))
-	task.Put("/edit", handlers.EditTask(taskCollection))
-	task.Delete("/delete", handlers.DeleteTask(taskCollection))
+	// Права в рабочем пространстве: viewer только читает, изменения — от editor
+	workspace := middleware.WorkspaceMiddleware(workspaceCollection, inviteCollection)
+	canWrite := middleware.RequireRoleForWrites(models.RoleEditor)
+	ownerOnly := middleware.RequireRole(models.RoleOwner)
+
+	api.Get("/stream", workspace, handlers.StreamTasks(bus))
+	api.Get("/ws", workspace, handlers.UpgradeWebSocket, handlers.TaskSocket(bus))
+
+	api.Get("/tasks/:id/history", workspace, handlers.GetTaskHistory(activityCollection))
+	api.Get("/tasks/export", workspace, handlers.ExportTasks(taskCollection))
+	api.Post("/tasks/import", workspace, canWrite, handlers.ImportTasks(taskCollection, projectCollection, publisher))
+	api.Post("/tasks/quick", workspace, canWrite, handlers.QuickAddTask(taskCollection, projectCollection, publisher))
+	api.Post("/tasks/bulk", workspace, canWrite, handlers.BulkTasks(taskCollection, projectCollection, undoCollection, publisher))
+	api.Post("/undo", handlers.Undo(undoCollection, taskCollection, labelCollection, workspaceCollection, inviteCollection, publisher))
+	api.Get("/activity", handlers.GetActivityFeed(activityCollection, workspaceCollection, inviteCollection))
+	api.Post("/calendar/token", handlers.RegenerateCalendarToken(userCollection))
+	api.Delete("/calendar/token", handlers.RevokeCalendarToken(userCollection))
+
+	ws := api.Group("/workspace")
+	ws.Post("/create", handlers.CreateWorkspace(workspaceCollection, inviteCollection))
+	ws.Get("/get", handlers.GetWorkspaces(workspaceCollection, inviteCollection))
+	ws.Post("/accept", handlers.AcceptInvite(workspaceCollection, inviteCollection))
+	ws.Get("/get/:workspace", workspace, handlers.GetWorkspace)
+	ws.Put("/edit/:workspace", workspace, ownerOnly, handlers.RenameWorkspace(workspaceCollection, inviteCollection))
+	ws.Post("/invite/:workspace", workspace, ownerOnly, handlers.InviteMember(workspaceCollection, inviteCollection, email))
+	ws.Get("/invites/:workspace", workspace, ownerOnly, handlers.GetInvites(workspaceCollection, inviteCollection))
+	ws.Delete("/invite/:workspace/:invite", workspace, ownerOnly, handlers.RevokeInvite(workspaceCollection, inviteCollection))
+	ws.Put("/member/:workspace/:user", workspace, ownerOnly, handlers.SetMemberRole(workspaceCollection, inviteCollection))
+	ws.Delete("/member/:workspace/:user", workspace, handlers.RemoveMember(workspaceCollection, inviteCollection))
+
+	// Доступны и viewer: расчёт без изменений и подписка самого пользователя на задачу.
+	// Регистрируются до группы /task, чтобы её canWrite не срабатывал раньше.
+	api.Post("/task/schedule", workspace, handlers.GetTaskSchedule(taskCollection))
+	api.Post("/task/recurrence/preview", workspace, handlers.PreviewRecurrence)
+	api.Post("/task/watch/:id", workspace, handlers.WatchTask(taskCollection, publisher, true))
+	api.Delete("/task/watch/:id", workspace, handlers.WatchTask(taskCollection, publisher, false))
+
+	task := api.Group("/task", workspace, canWrite)
+	task.Post("/create", handlers.CreateTask(taskCollection, projectCollection, notifications, publisher))
+	task.Get("/get", handlers.GetTasks(taskCollection, projectCollection))
+	task.Put("/edit", handlers.EditTask(taskCollection, projectCollection, notifications, publisher))
+	task.Delete("/delete/:id", handlers.DeleteTask(taskCollection, undoCollection, notifications, publisher))
+	task.Post("/subtask/:id", handlers.CreateSubtask(taskCollection, projectCollection, publisher))
+	task.Put("/move/:id", handlers.MoveTask(taskCollection, publisher))
+	task.Get("/trash", handlers.GetTrash(taskCollection))
+	task.Post("/restore/:id", handlers.RestoreTask(taskCollection, publisher))
+	task.Delete("/trash/:id", handlers.PurgeTask(taskCollection, commentCollection, attachmentCollection, blobStore))
+	task.Get("/versions/:id", handlers.GetTaskVersions(versionCollection))
+	task.Get("/versions/:id/diff", handlers.DiffTaskVersions(versionCollection))
+	task.Get("/versions/:id/:version", handlers.GetTaskVersion(versionCollection))
+	task.Post("/versions/:id/:version/restore", handlers.RestoreTaskVersion(taskCollection, versionCollection, projectCollection, publisher))
+	task.Get("/assigned", handlers.GetAssignedTasks(taskCollection, workspaceCollection, inviteCollection))
+	task.Put("/assign/:id", handlers.AssignTask(taskCollection, notifications, publisher))
+	task.Delete("/assign/:id", handlers.UnassignTask(taskCollection, notifications, publisher))
+	task.Get("/tree/:id", handlers.GetTaskTree(taskCollection))
+	task.Post("/dependency/:id", handlers.AddDependency(taskCollection, publisher))
+	task.Delete("/dependency/:id/:blocker", handlers.RemoveDependency(taskCollection, publisher))
+	task.Get("/occurrences/:id", handlers.PreviewTaskOccurrences(taskCollection))
+	task.Post("/checklist/:id", handlers.AddChecklistItem(taskCollection, publisher))
+	task.Put("/checklist/:id/:item/toggle", handlers.ToggleChecklistItem(taskCollection, publisher))
+	task.Put("/checklist/:id/:item/move", handlers.MoveChecklistItem(taskCollection, publisher))
+	task.Delete("/checklist/:id/:item", handlers.DeleteChecklistItem(taskCollection, publisher))
+
+	template := api.Group("/template", workspace, canWrite)
+	template.Post("/create", handlers.CreateTemplate(templateCollection))
+	template.Get("/get", handlers.GetTemplates(templateCollection))
+	template.Get("/get/:id", handlers.GetTemplate(templateCollection))
+	template.Put("/edit/:id", handlers.EditTemplate(templateCollection))
+	template.Delete("/delete/:id", handlers.DeleteTemplate(templateCollection))
+	template.Post("/instantiate/:id", handlers.InstantiateTemplate(templateCollection, taskCollection, projectCollection, publisher))
+
+	timer := api.Group("/time", workspace, canWrite)
+	timer.Post("/start/:id", handlers.StartTimer(timeCollection, taskCollection))
+	timer.Post("/stop", handlers.StopTimer(timeCollection, taskCollection))
+	timer.Get("/running", handlers.GetRunningTimer(timeCollection, taskCollection))
+	timer.Get("/task/:id", handlers.GetTaskTime(timeCollection, taskCollection))
+	timer.Post("/entry/:id", handlers.CreateTimeEntry(timeCollection, taskCollection))
+	timer.Put("/entry/:id", handlers.EditTimeEntry(timeCollection, taskCollection))
+	timer.Delete("/entry/:id", handlers.DeleteTimeEntry(timeCollection, taskCollection))
+	timer.Get("/report", handlers.GetTimesheet(timeCollection, taskCollection, projectCollection, userCollection))
+
+	label := api.Group("/label", workspace, canWrite)
+	label.Post("/create", handlers.CreateLabel(labelCollection, taskCollection))
+	label.Get("/get", handlers.GetLabels(labelCollection, taskCollection))
+	label.Get("/autocomplete", handlers.AutocompleteTags(labelCollection, taskCollection))
+	label.Put("/rename/:id", handlers.RenameLabel(labelCollection, taskCollection, publisher))
+	label.Post("/merge", handlers.MergeLabels(labelCollection, taskCollection, undoCollection, publisher))
+	label.Delete("/delete/:id", handlers.DeleteLabel(labelCollection, taskCollection, undoCollection, publisher))
+
+	comment := api.Group("/comment", workspace, canWrite)
+	comment.Post("/create/:id", handlers.CreateComment(commentCollection, taskCollection, userCollection, notifications))
+	comment.Get("/get/:id", handlers.GetComments(commentCollection, taskCollection))
+	comment.Put("/edit/:id", handlers.EditComment(commentCollection, taskCollection, userCollection))
+	comment.Delete("/delete/:id", handlers.DeleteComment(commentCollection, taskCollection))
+
+	attachment := api.Group("/attachment", workspace, canWrite)
+	attachment.Post("/upload/:id", handlers.UploadAttachments(attachmentCollection, taskCollection, blobStore))
+	attachment.Get("/get/:id", handlers.GetAttachments(attachmentCollection, taskCollection))
+	attachment.Get("/download/:id", handlers.DownloadAttachment(attachmentCollection, taskCollection, blobStore))
+	attachment.Delete("/delete/:id", handlers.DeleteAttachment(attachmentCollection, taskCollection, blobStore))
+
+	project := api.Group("/project", workspace, canWrite)
+	project.Post("/create", handlers.CreateProject(projectCollection, taskCollection))
+	project.Get("/get", handlers.GetProjects(projectCollection, taskCollection))
+	project.Get("/get/:id", handlers.GetProject(projectCollection, taskCollection))
+	project.Get("/stats", handlers.GetProjectStats(projectCollection, taskCollection))
+	project.Get("/tasks/:id", handlers.GetProjectTasks(projectCollection, taskCollection))
+	project.Put("/edit/:id", handlers.EditProject(projectCollection, taskCollection))
+	project.Put("/archive/:id", handlers.ArchiveProject(projectCollection, taskCollection, true))
+	project.Put("/unarchive/:id", handlers.ArchiveProject(projectCollection, taskCollection, false))
+
+	webhook := api.Group("/webhook", workspace, ownerOnly)
+	webhook.Post("/create", handlers.CreateWebhook(webhookCollection, deliveryCollection))
+	webhook.Get("/get", handlers.GetWebhooks(webhookCollection, deliveryCollection))
+	webhook.Put("/edit/:id", handlers.EditWebhook(webhookCollection, deliveryCollection))
+	webhook.Delete("/delete/:id", handlers.DeleteWebhook(webhookCollection, deliveryCollection))
+	webhook.Get("/deliveries/:id", handlers.GetWebhookDeliveries(webhookCollection, deliveryCollection))
+	webhook.Post("/redeliver/:id", handlers.RedeliverWebhook(webhookCollection, deliveryCollection, webhookDispatcher))
+
+	notification := api.Group("/notifications")
+	notification.Get("/", handlers.GetNotifications(notificationCollection))
+	notification.Post("/read/:id", handlers.MarkNotificationRead(notificationCollection))
+	notification.Post("/read-all", handlers.MarkAllNotificationsRead(notificationCollection))
+	notification.Get("/preferences", handlers.GetNotificationPreferences(userCollection))
+	notification.Put("/preferences", handlers.UpdateNotificationPreferences(userCollection))
 
 	app.Listen(":3000")
 }
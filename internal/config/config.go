@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2/log"
@@ -16,12 +17,39 @@ type Config struct {
 	DatabaseName         string
 	JWTSecretKey         string
 	DatabaseHost         string
+	DatabaseURI          string
 	AppPort              string
 	EncryptCookieKey     string `json:"encrypt_cookie_key" env:"ENCRYPT_COOKIE_KEY"`
 	AccessTokenLifetime  int
 	RefreshTokenLifetime int
 	UseHttps             bool
 	ContextTimeout       time.Duration
+	MaxTaskDepth         int
+	AutoCompleteParent   bool
+	BlockCompletion      bool
+	BlobStore            string
+	BlobDir              string
+	MaxUploadSize        int
+	AllowedMimeTypes     []string
+	ReminderOffsets      []time.Duration
+	SchedulerInterval    time.Duration
+	SchedulerLease       time.Duration
+	SMTPHost             string
+	SMTPPort             string
+	SMTPUser             string
+	SMTPPassword         string
+	SMTPFrom             string
+	SMTPTimeout          time.Duration
+	EventSource          string
+	EventHistory         int
+	WebhookMaxAttempts   int
+	WebhookDisableAfter  int
+	WebhookTimeout       time.Duration
+	WebhookAllowLocal    bool
+	InviteTTL            time.Duration
+	TrashRetention       time.Duration
+	TaskVersionLimit     int
+	UndoWindow           time.Duration
 }
 
 func LoadConfig() *Config {
@@ -32,12 +60,39 @@ func LoadConfig() *Config {
 		DatabaseName:         getEnv("DB_NAME", "Database"),
 		JWTSecretKey:         getEnv("JWT_SECRET_KEY", "secret_key"),
 		DatabaseHost:         getEnv("DB_HOST", "localhost"),
+		DatabaseURI:          getEnv("DB_URI", ""),
 		AppPort:              getEnv("APP_PORT", "8080"),
 		EncryptCookieKey:     getValidAESKey("ENCRYPT_COOKIE_KEY"),
 		AccessTokenLifetime:  parseInt(getEnv("ACCESS_TOKEN_LIFETIME", "15")),
 		RefreshTokenLifetime: parseInt(getEnv("REFRESH_TOKEN_LIFETIME", "43200")),
 		UseHttps:             parseBool(getEnv("USE_HTTPS", "false")),
 		ContextTimeout:       time.Duration(parseInt(getEnv("CONTEXT_TIMEOUT", "10"))) * time.Second,
+		MaxTaskDepth:         parseInt(getEnv("MAX_TASK_DEPTH", "3")),
+		AutoCompleteParent:   parseBool(getEnv("AUTO_COMPLETE_PARENT", "false")),
+		BlockCompletion:      parseBool(getEnv("BLOCK_COMPLETION_ON_OPEN_DEPENDENCIES", "true")),
+		BlobStore:            getEnv("BLOB_STORE", "local"),
+		BlobDir:              getEnv("BLOB_DIR", "uploads"),
+		MaxUploadSize:        parseInt(getEnv("MAX_UPLOAD_SIZE", "10485760")),
+		AllowedMimeTypes:     parseList(getEnv("ALLOWED_MIME_TYPES", "image/png,image/jpeg,image/gif,image/webp,application/pdf,text/plain,application/json,application/zip")),
+		ReminderOffsets:      parseDurations(getEnv("REMINDER_OFFSETS", "24h,1h")),
+		SchedulerInterval:    time.Duration(parseInt(getEnv("SCHEDULER_INTERVAL", "5"))) * time.Second,
+		SchedulerLease:       time.Duration(parseInt(getEnv("SCHEDULER_LEASE", "60"))) * time.Second,
+		SMTPHost:             getEnv("SMTP_HOST", ""),
+		SMTPPort:             getEnv("SMTP_PORT", "587"),
+		SMTPUser:             getEnv("SMTP_USER", ""),
+		SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:             getEnv("SMTP_FROM", "noreply@localhost"),
+		SMTPTimeout:          time.Duration(parseInt(getEnv("SMTP_TIMEOUT", "10"))) * time.Second,
+		EventSource:          getEnv("EVENT_SOURCE", "local"),
+		EventHistory:         parseInt(getEnv("EVENT_HISTORY", "1000")),
+		WebhookMaxAttempts:   parseInt(getEnv("WEBHOOK_MAX_ATTEMPTS", "8")),
+		WebhookDisableAfter:  parseInt(getEnv("WEBHOOK_DISABLE_AFTER", "5")),
+		WebhookTimeout:       time.Duration(parseInt(getEnv("WEBHOOK_TIMEOUT", "10"))) * time.Second,
+		WebhookAllowLocal:    parseBool(getEnv("WEBHOOK_ALLOW_LOCALHOST", "false")),
+		InviteTTL:            time.Duration(parseInt(getEnv("INVITE_TTL", "168"))) * time.Hour,
+		TrashRetention:       time.Duration(parseInt(getEnv("TRASH_RETENTION_DAYS", "30"))) * 24 * time.Hour,
+		TaskVersionLimit:     parseInt(getEnv("TASK_VERSION_LIMIT", "50")),
+		UndoWindow:           time.Duration(parseInt(getEnv("UNDO_WINDOW", "30"))) * time.Second,
 	}
 }
 
@@ -63,6 +118,29 @@ func parseBool(s string) bool {
 	return s == "true"
 }
 
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseDurations(s string) []time.Duration {
+	var durations []time.Duration
+	for _, item := range parseList(s) {
+		d, err := time.ParseDuration(item)
+		if err != nil {
+			log.Errorf("Error parsing duration: %v", err)
+			continue
+		}
+		durations = append(durations, d)
+	}
+	return durations
+}
+
 func parseInt(s string) int {
 	i, err := strconv.Atoi(s)
 	if err != nil {
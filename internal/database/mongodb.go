@@ -2,9 +2,11 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"task_manager/internal/config"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -20,7 +22,11 @@ func Connect() (*MongoClient, error) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
 	defer cancel()
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI("mongodb://"+cfg.DatabaseHost+":"+cfg.DatabasePort))
+	uri := cfg.DatabaseURI
+	if uri == "" {
+		uri = "mongodb://" + cfg.DatabaseHost + ":" + cfg.DatabasePort
+	}
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
 	if err != nil {
 		return nil, err
 	}
@@ -34,6 +40,24 @@ func Connect() (*MongoClient, error) {
 		Database: client.Database(cfg.DatabaseName),
 	}, nil
 }
+
+// RequireReplicaSet проверяет, что сервер поддерживает транзакции: это replica set или mongos.
+// Одиночный mongod транзакции отклоняет, поэтому приложение на нём не запускается.
+func (mc *MongoClient) RequireReplicaSet(ctx context.Context) error {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := mc.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return err
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return fmt.Errorf("MongoDB is not a replica set: transactions are unavailable. " +
+			"Start mongod with --replSet, run rs.initiate() and point DB_URI at it (see README)")
+	}
+	return nil
+}
+
 func (mc *MongoClient) Disconnect(ctx context.Context) error {
 	err := mc.Client.Disconnect(ctx)
 	if err != nil {
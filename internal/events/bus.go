@@ -0,0 +1,158 @@
+package events
+
+import (
+	"strconv"
+	"sync"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TaskCreated = "task.created"
+	TaskUpdated = "task.updated"
+	TaskDeleted = "task.deleted"
+)
+
+// subscriberBuffer — сколько событий может накопиться у медленного подписчика
+// до того, как он будет отключён; после переподключения он догонит пропущенное по Last-Event-ID
+const subscriberBuffer = 64
+
+// listenerBuffer — очередь событий обработчика; при переполнении Publish ждёт, а не теряет события
+const listenerBuffer = 1024
+
+// Event — изменение задачи рабочего пространства
+type Event struct {
+	ID          string             `json:"id"`
+	Type        string             `json:"type"`
+	WorkspaceID primitive.ObjectID `json:"workspace_id"`
+	TaskID      primitive.ObjectID `json:"task_id"`
+	Task        *models.Task       `json:"task,omitempty"`
+	Time        time.Time          `json:"time"`
+}
+
+// Publisher принимает события об изменениях задач
+type Publisher interface {
+	Publish(e Event)
+}
+
+type discard struct{}
+
+func (discard) Publish(Event) {}
+
+// Discard игнорирует события; используется, когда события берутся из change stream
+var Discard Publisher = discard{}
+
+type subscriber struct {
+	workspaceID primitive.ObjectID
+	ch          chan Event
+}
+
+// Bus рассылает события подписчикам и хранит последние события для возобновления по Last-Event-ID
+type Bus struct {
+	mu        sync.Mutex
+	prefix    string
+	seq       uint64
+	history   []Event
+	size      int
+	subs      map[*subscriber]struct{}
+	listeners []chan Event
+}
+
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		// Префикс отличает идентификаторы разных запусков, чтобы после рестарта
+		// старый Last-Event-ID не совпал с новым событием
+		prefix: strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:   historySize,
+		subs:   map[*subscriber]struct{}{},
+	}
+}
+
+// Listen регистрирует обработчик всех событий. Обработчик вызывается в отдельной горутине
+// в порядке публикации, поэтому медленный обработчик (например, запись доставок webhook в базу)
+// не задерживает запросы, которые публикуют события.
+func (b *Bus) Listen(fn func(Event)) {
+	ch := make(chan Event, listenerBuffer)
+	go func() {
+		for e := range ch {
+			fn(e)
+		}
+	}()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, ch)
+}
+
+// Publish рассылает событие. Если у события нет ID, он назначается шиной.
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	if e.ID == "" {
+		b.seq++
+		e.ID = b.prefix + "-" + strconv.FormatUint(b.seq, 10)
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if b.size > 0 {
+		if len(b.history) >= b.size {
+			b.history = b.history[1:]
+		}
+		b.history = append(b.history, e)
+	}
+	for sub := range b.subs {
+		if sub.workspaceID != e.WorkspaceID {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+	listeners := b.listeners
+	b.mu.Unlock()
+
+	for _, ch := range listeners {
+		ch <- e
+	}
+}
+
+// Subscribe подписывает на события рабочего пространства. Если lastEventID задан, возвращает
+// пропущенные после него события; ok=false означает, что событие уже вытеснено из истории
+// и клиенту нужно заново загрузить задачи. Канал закрывается при отписке или переполнении.
+func (b *Bus) Subscribe(workspaceID primitive.ObjectID, lastEventID string) (<-chan Event, []Event, bool, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []Event
+	ok := true
+	if lastEventID != "" {
+		ok = false
+		for i, e := range b.history {
+			if e.ID == lastEventID {
+				ok = true
+				for _, missed := range b.history[i+1:] {
+					if missed.WorkspaceID == workspaceID {
+						backlog = append(backlog, missed)
+					}
+				}
+				break
+			}
+		}
+	}
+
+	sub := &subscriber{workspaceID: workspaceID, ch: make(chan Event, subscriberBuffer)}
+	b.subs[sub] = struct{}{}
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, found := b.subs[sub]; found {
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+	return sub.ch, backlog, ok, unsubscribe
+}
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"context"
+	"task_manager/internal/models"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const watchRetryDelay = 5 * time.Second
+
+type taskChange struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument             *models.Task `bson:"fullDocument"`
+	FullDocumentBeforeChange *models.Task `bson:"fullDocumentBeforeChange"`
+}
+
+// WatchTasks публикует в bus изменения коллекции задач из change stream, чтобы события
+// видели клиенты всех экземпляров приложения. ID события — resume token, он одинаков
+// на всех экземплярах, поэтому Last-Event-ID работает при переподключении к любому из них.
+// Блокирует до отмены ctx.
+func WatchTasks(ctx context.Context, tasks *mongo.Collection, bus *Bus) error {
+	// Для удалений владелец задачи известен только из pre-image
+	err := tasks.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: tasks.Name()},
+		{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}},
+	}).Err()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to enable change stream pre-images, task deletions will not be streamed")
+	}
+
+	var resumeToken bson.Raw
+	for {
+		resumeToken = watchTasks(ctx, tasks, bus, resumeToken)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(watchRetryDelay):
+		}
+	}
+}
+
+// watchTasks читает поток до ошибки и возвращает последний обработанный resume token
+func watchTasks(ctx context.Context, tasks *mongo.Collection, bus *Bus, resumeToken bson.Raw) bson.Raw {
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if resumeToken != nil {
+		opts.SetResumeAfter(resumeToken)
+	}
+	stream, err := tasks.Watch(ctx, mongo.Pipeline{}, opts)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("failed to open task change stream")
+		}
+		return resumeToken
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var change taskChange
+		if err := stream.Decode(&change); err != nil {
+			log.Error().Err(err).Msg("failed to decode task change")
+			continue
+		}
+		resumeToken = stream.ResumeToken()
+		if e, ok := changeEvent(change); ok {
+			e.ID, _ = resumeToken.Lookup("_data").StringValueOK()
+			bus.Publish(e)
+		}
+	}
+	if err := stream.Err(); err != nil && ctx.Err() == nil {
+		log.Error().Err(err).Msg("task change stream interrupted")
+	}
+	return resumeToken
+}
+
+func changeEvent(change taskChange) (Event, bool) {
+	e := Event{TaskID: change.DocumentKey.ID, Time: time.Now()}
+	switch change.OperationType {
+	case "insert":
+		e.Type = TaskCreated
+	case "update", "replace":
+		e.Type = TaskUpdated
+	case "delete":
+		e.Type = TaskDeleted
+		// Задачи из корзины клиенты уже считают удалёнными
+		if change.FullDocumentBeforeChange == nil || change.FullDocumentBeforeChange.DeletedAt != nil {
+			return e, false
+		}
+		e.WorkspaceID = change.FullDocumentBeforeChange.WorkspaceID
+		return e, true
+	default:
+		return e, false
+	}
+	// Задача могла быть удалена до того, как сработал lookup
+	if change.FullDocument == nil {
+		return e, false
+	}
+	// Перенос в корзину и восстановление для клиентов выглядят как удаление и создание
+	if change.FullDocument.DeletedAt != nil {
+		e.Type, e.WorkspaceID = TaskDeleted, change.FullDocument.WorkspaceID
+		return e, true
+	}
+	if before := change.FullDocumentBeforeChange; before != nil && before.DeletedAt != nil {
+		e.Type = TaskCreated
+	}
+	change.FullDocument.CalcChecklistProgress()
+	e.WorkspaceID = change.FullDocument.WorkspaceID
+	e.Task = change.FullDocument
+	return e, true
+}
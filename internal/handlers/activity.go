@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+// actorContext возвращает контекст, в котором изменения задач записываются в историю от имени текущего пользователя
+func actorContext(c *fiber.Ctx) context.Context {
+	user := c.Locals("user").(*models.User)
+	return repositories.WithActor(context.Background(), user.ID)
+}
+
+func GetTaskHistory(activityCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		page, limit := parsePagination(c)
+		r := repositories.NewActivityRepository(activityCollection)
+		history, total, err := r.GetTaskHistory(taskID, workspace.ID, page, limit, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if total == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"history": history, "page": page, "limit": limit, "total": total})
+	}
+}
+
+// GetActivityFeed возвращает изменения задач, сделанные текущим пользователем,
+// во всех рабочих пространствах, где он состоит
+func GetActivityFeed(activityCollection, workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		wr := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		workspaces, err := wr.GetWorkspaces(user.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		workspaceIDs := make([]primitive.ObjectID, len(workspaces))
+		for i, w := range workspaces {
+			workspaceIDs[i] = w.ID
+		}
+
+		page, limit := parsePagination(c)
+		r := repositories.NewActivityRepository(activityCollection)
+		activities, total, err := r.GetUserActivity(user.ID, workspaceIDs, page, limit, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"activity": activities, "page": page, "limit": limit, "total": total})
+	}
+}
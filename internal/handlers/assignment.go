@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/notifier"
+	"task_manager/internal/repositories"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+type assignRequest struct {
+	AssigneeID string `json:"assignee_id" validate:"required"`
+}
+
+func AssignTask(collection *mongo.Collection, n notifier.Notifier, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		req := new(assignRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		assigneeID, err := primitive.ObjectIDFromHex(req.AssigneeID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid assignee ID"})
+		}
+		if workspace.Role(assigneeID) == "" {
+			return c.Status(400).JSON(fiber.Map{"message": "Assignee is not a member of the workspace"})
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		task, err := r.GetTask(taskID, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		if _, err := r.AssignTask(taskID, &assigneeID, workspace.ID, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTaskUpdated(pub, r, workspace.ID, ctx, taskID)
+		if task.AssigneeID == nil || *task.AssigneeID != assigneeID {
+			notifyUsers(n, []primitive.ObjectID{assigneeID}, user.ID, notifier.Message{
+				TaskID: &task.ID,
+				Type:   models.NotificationTaskAssigned,
+				Title:  "Task assigned",
+				Body:   fmt.Sprintf("%s assigned %q to you", user.Username, task.Title),
+			}, ctx)
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Task assigned successfully"})
+	}
+}
+
+func UnassignTask(collection *mongo.Collection, n notifier.Notifier, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		r := repositories.NewTaskRepository(collection)
+		task, err := r.GetTask(taskID, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		if task.AssigneeID == nil {
+			return c.Status(200).JSON(fiber.Map{"message": "Task unassigned successfully"})
+		}
+		if _, err := r.AssignTask(taskID, nil, workspace.ID, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTaskUpdated(pub, r, workspace.ID, ctx, taskID)
+		notifyUsers(n, []primitive.ObjectID{*task.AssigneeID}, user.ID, notifier.Message{
+			TaskID: &task.ID,
+			Type:   models.NotificationTaskAssigned,
+			Title:  "Task unassigned",
+			Body:   fmt.Sprintf("%s unassigned you from %q", user.Username, task.Title),
+		}, ctx)
+		return c.Status(200).JSON(fiber.Map{"message": "Task unassigned successfully"})
+	}
+}
+
+// WatchTask подписывает текущего пользователя на изменения задачи (watch=false — отписывает)
+func WatchTask(collection *mongo.Collection, pub events.Publisher, watch bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		r := repositories.NewTaskRepository(collection)
+		result, err := r.SetWatching(taskID, user.ID, workspace.ID, watch, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if result.MatchedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		if result.ModifiedCount > 0 {
+			publishTaskUpdated(pub, r, workspace.ID, ctx, taskID)
+		}
+		if watch {
+			return c.Status(200).JSON(fiber.Map{"message": "Task watched successfully"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Task unwatched successfully"})
+	}
+}
+
+// GetAssignedTasks возвращает задачи, назначенные текущему пользователю, во всех его рабочих пространствах
+func GetAssignedTasks(collection, workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		wr := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		workspaces, err := wr.GetWorkspaces(user.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		workspaceIDs := make([]primitive.ObjectID, len(workspaces))
+		for i, w := range workspaces {
+			workspaceIDs[i] = w.ID
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		tasks, err := r.GetAssignedTasks(user.ID, workspaceIDs, parseTaskFilter(c), ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if !c.QueryBool("include_completed", false) {
+			open := tasks[:0]
+			for _, task := range tasks {
+				if task.Status != "completed" {
+					open = append(open, task)
+				}
+			}
+			tasks = open
+		}
+		return c.Status(200).JSON(fiber.Map{"tasks": tasks})
+	}
+}
+
+// taskFollowers возвращает исполнителя и наблюдателей задачи
+func taskFollowers(task *models.Task) []primitive.ObjectID {
+	followers := make([]primitive.ObjectID, 0, len(task.Watchers)+1)
+	if task.AssigneeID != nil {
+		followers = append(followers, *task.AssigneeID)
+	}
+	return append(followers, task.Watchers...)
+}
+
+// notifyTaskChanged сообщает исполнителю и наблюдателям об изменениях задачи
+func notifyTaskChanged(before, after *models.Task, actor *models.User, n notifier.Notifier, ctx context.Context) {
+	changes := describeTaskChanges(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	notifyUsers(n, taskFollowers(before), actor.ID, notifier.Message{
+		TaskID: &before.ID,
+		Type:   models.NotificationTaskUpdated,
+		Title:  "Task updated",
+		Body:   fmt.Sprintf("%s updated %q: %s", actor.Username, before.Title, strings.Join(changes, ", ")),
+	}, ctx)
+}
+
+// notifyTaskDeleted сообщает исполнителю и наблюдателям об удалении задачи
+func notifyTaskDeleted(task *models.Task, actor *models.User, n notifier.Notifier, ctx context.Context) {
+	notifyUsers(n, taskFollowers(task), actor.ID, notifier.Message{
+		Type:  models.NotificationTaskUpdated,
+		Title: "Task deleted",
+		Body:  fmt.Sprintf("%s deleted %q", actor.Username, task.Title),
+	}, ctx)
+}
+
+func describeTaskChanges(before, after *models.Task) []string {
+	var changes []string
+	if before.Title != after.Title {
+		changes = append(changes, fmt.Sprintf("title changed to %q", after.Title))
+	}
+	if before.Status != after.Status {
+		changes = append(changes, fmt.Sprintf("status %s → %s", before.Status, after.Status))
+	}
+	if before.Priority != after.Priority {
+		changes = append(changes, fmt.Sprintf("priority %s → %s", before.Priority, after.Priority))
+	}
+	if !sameTime(before.DueDate, after.DueDate) {
+		if after.DueDate == nil {
+			changes = append(changes, "due date removed")
+		} else {
+			changes = append(changes, fmt.Sprintf("due %s", after.DueDate.Format("2006-01-02 15:04 MST")))
+		}
+	}
+	if before.Description != after.Description {
+		changes = append(changes, "description changed")
+	}
+	return changes
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// notifyUsers отправляет уведомление каждому получателю один раз, не уведомляя автора изменения.
+// Ошибки доставки не влияют на результат запроса.
+func notifyUsers(n notifier.Notifier, recipients []primitive.ObjectID, actorID primitive.ObjectID, msg notifier.Message, ctx context.Context) {
+	seen := map[primitive.ObjectID]bool{actorID: true}
+	for _, userID := range recipients {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		msg.UserID = userID
+		if err := n.Notify(ctx, msg); err != nil {
+			log.Error().Err(err).Str("user_id", userID.Hex()).Msg("failed to deliver notification")
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"task_manager/internal/storage"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+func UploadAttachments(attachmentCollection, taskCollection *mongo.Collection, store storage.BlobStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		tr := repositories.NewTaskRepository(taskCollection)
+		if _, err := tr.GetTask(taskID, workspace.ID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+
+		form, err := c.MultipartForm()
+		if err != nil || len(form.File["file"]) == 0 {
+			return c.Status(400).JSON(fiber.Map{"message": "No files uploaded"})
+		}
+		for _, fh := range form.File["file"] {
+			if fh.Size > int64(cfg.MaxUploadSize) {
+				return c.Status(413).JSON(fiber.Map{"message": fmt.Sprintf("File %s exceeds the maximum size of %d bytes", fh.Filename, cfg.MaxUploadSize)})
+			}
+		}
+
+		r := repositories.NewAttachmentRepository(attachmentCollection)
+		attachments := []models.Attachment{}
+		for _, fh := range form.File["file"] {
+			f, err := fh.Open()
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid file"})
+			}
+			mtype, err := mimetype.DetectReader(f)
+			if err == nil {
+				_, err = f.Seek(0, 0)
+			}
+			if err != nil {
+				f.Close()
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid file"})
+			}
+			if !isAllowedMimeType(mtype) {
+				f.Close()
+				return c.Status(415).JSON(fiber.Map{"message": fmt.Sprintf("File type %s is not allowed", mtype.String())})
+			}
+
+			attachment := models.Attachment{
+				ID:          primitive.NewObjectID(),
+				TaskID:      taskID,
+				UserID:      user.ID,
+				Filename:    fh.Filename,
+				ContentType: mtype.String(),
+			}
+			attachment.Key = attachment.ID.Hex()
+			attachment.Size, err = store.Put(ctx, attachment.Key, f)
+			f.Close()
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+			}
+			if _, err := r.CreateAttachment(&attachment, ctx); err != nil {
+				store.Delete(ctx, attachment.Key)
+				return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+			}
+			attachments = append(attachments, attachment)
+		}
+		return c.Status(201).JSON(fiber.Map{"message": "Files uploaded successfully", "attachments": attachments})
+	}
+}
+
+func GetAttachments(attachmentCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		tr := repositories.NewTaskRepository(taskCollection)
+		if _, err := tr.GetTask(taskID, workspace.ID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		r := repositories.NewAttachmentRepository(attachmentCollection)
+		attachments, err := r.GetAttachments([]primitive.ObjectID{taskID}, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"attachments": attachments})
+	}
+}
+
+func DownloadAttachment(attachmentCollection, taskCollection *mongo.Collection, store storage.BlobStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		attachment, err := findAttachment(c, attachmentCollection, taskCollection, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Attachment not found"})
+		}
+
+		c.Set(fiber.HeaderAcceptRanges, "bytes")
+		c.Set(fiber.HeaderContentType, attachment.ContentType)
+		c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+
+		offset, length := int64(0), attachment.Size
+		status := 200
+		if header := c.Get(fiber.HeaderRange); header != "" {
+			start, end, ok := parseRange(header, attachment.Size)
+			if !ok {
+				c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", attachment.Size))
+				return c.Status(416).JSON(fiber.Map{"message": "Requested range not satisfiable"})
+			}
+			offset, length = start, end-start+1
+			status = 206
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, end, attachment.Size))
+		}
+
+		reader, err := store.Open(ctx, attachment.Key, offset, length)
+		if err == storage.ErrBlobNotFound {
+			return c.Status(404).JSON(fiber.Map{"message": "Attachment not found"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(status).SendStream(reader, int(length))
+	}
+}
+
+func DeleteAttachment(attachmentCollection, taskCollection *mongo.Collection, store storage.BlobStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		attachment, err := findAttachment(c, attachmentCollection, taskCollection, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Attachment not found"})
+		}
+		if err := store.Delete(ctx, attachment.Key); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		r := repositories.NewAttachmentRepository(attachmentCollection)
+		if _, err := r.DeleteAttachments([]primitive.ObjectID{attachment.ID}, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Attachment deleted successfully"})
+	}
+}
+
+// PurgeOrphanedAttachments удаляет вложения и блобы задач, которых больше нет
+func PurgeOrphanedAttachments(attachmentCollection, taskCollection *mongo.Collection, store storage.BlobStore, ctx context.Context) error {
+	r := repositories.NewAttachmentRepository(attachmentCollection)
+	orphans, err := r.FindOrphans(taskCollection.Name(), ctx)
+	if err != nil {
+		return err
+	}
+	return removeAttachments(orphans, r, store, ctx)
+}
+
+// cleanupAttachments удаляет вложения удалённых задач
+func cleanupAttachments(taskIDs []primitive.ObjectID, attachmentCollection *mongo.Collection, store storage.BlobStore, ctx context.Context) error {
+	r := repositories.NewAttachmentRepository(attachmentCollection)
+	attachments, err := r.GetAttachments(taskIDs, ctx)
+	if err != nil {
+		return err
+	}
+	return removeAttachments(attachments, r, store, ctx)
+}
+
+func removeAttachments(attachments []models.Attachment, r *repositories.AttachmentRepository, store storage.BlobStore, ctx context.Context) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(attachments))
+	for _, attachment := range attachments {
+		if err := store.Delete(ctx, attachment.Key); err != nil {
+			// Запись остаётся, блоб будет удалён при следующей очистке
+			log.Error().Err(err).Str("key", attachment.Key).Msg("failed to delete blob")
+			continue
+		}
+		ids = append(ids, attachment.ID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.DeleteAttachments(ids, ctx)
+	return err
+}
+
+func findAttachment(c *fiber.Ctx, attachmentCollection, taskCollection *mongo.Collection, workspaceID primitive.ObjectID, ctx context.Context) (*models.Attachment, error) {
+	attachmentID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, err
+	}
+	r := repositories.NewAttachmentRepository(attachmentCollection)
+	attachment, err := r.GetAttachment(attachmentID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	tr := repositories.NewTaskRepository(taskCollection)
+	if _, err := tr.GetTask(attachment.TaskID, workspaceID, ctx); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func isAllowedMimeType(mtype *mimetype.MIME) bool {
+	for _, allowed := range cfg.AllowedMimeTypes {
+		if mtype.Is(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRange разбирает заголовок Range с одним диапазоном байт.
+// Возвращает границы включительно; ok=false, если диапазон некорректен или вне файла.
+func parseRange(header string, size int64) (int64, int64, bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") || size == 0 {
+		return 0, 0, false
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	if first == "" {
+		// bytes=-N — последние N байт
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, true
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+// bulkMaxTasks — сколько задач можно изменить одним запросом
+const bulkMaxTasks = 500
+
+type bulkFilterRequest struct {
+	Tags      []string `json:"tags"`
+	TagsMode  string   `json:"tags_mode" validate:"omitempty,oneof=any all"`
+	ProjectID string   `json:"project_id"`
+	Status    string   `json:"status" validate:"omitempty,oneof=pending in_progress completed"`
+	Priority  string   `json:"priority" validate:"omitempty,oneof=low medium high"`
+}
+
+type bulkRequest struct {
+	IDs       []string           `json:"ids" validate:"max=500"`
+	Filter    *bulkFilterRequest `json:"filter"`
+	Operation string             `json:"operation" validate:"required,oneof=set_status set_priority add_tag delete move_project"`
+	Value     string             `json:"value"`
+	DryRun    bool               `json:"dry_run"`
+}
+
+// BulkTasks применяет одну операцию к задачам, заданным списком ID или фильтром.
+// В режиме dry_run возвращает те же результаты по задачам, ничего не изменяя.
+func BulkTasks(collection, projectCollection, undoCollection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		req := new(bulkRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		if (len(req.IDs) > 0) == (req.Filter != nil) {
+			return c.Status(400).JSON(fiber.Map{"message": "Either ids or filter is required"})
+		}
+		op, err := parseBulkOperation(req, workspace.ID, projectCollection, collection, ctx)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		var taskIDs []primitive.ObjectID
+		if req.Filter != nil {
+			filter, err := parseBulkFilter(req.Filter)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+			}
+			tasks, err := r.GetTasks(workspace.ID, filter, ctx)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+			}
+			if len(tasks) > bulkMaxTasks {
+				return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("Filter matches more than %d tasks", bulkMaxTasks)})
+			}
+			for _, task := range tasks {
+				taskIDs = append(taskIDs, task.ID)
+			}
+		} else if taskIDs, err = parseObjectIDs(req.IDs); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+
+		results, affected, undo, err := r.BulkApply(workspace.ID, taskIDs, op, req.DryRun, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		response := fiber.Map{"results": results, "dry_run": req.DryRun}
+		if req.DryRun || len(undo.Items) == 0 {
+			return c.Status(200).JSON(response)
+		}
+
+		if op.Type == models.BulkDelete {
+			publishTasksDeleted(pub, workspace.ID, affected)
+		} else {
+			publishTaskUpdated(pub, r, workspace.ID, ctx, affected...)
+		}
+		if op.Type == models.BulkSetStatus && op.Status == "completed" {
+			if err := completeBulkTasks(r, affected, workspace.ID, user.Location(), pub, ctx); err != nil {
+				return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+			}
+		}
+		return c.Status(200).JSON(issueUndo(response, undoCollection, user, workspace.ID, models.UndoTaskBulk, undo, ctx))
+	}
+}
+
+func parseBulkOperation(req *bulkRequest, workspaceID primitive.ObjectID, projectCollection, taskCollection *mongo.Collection, ctx context.Context) (models.BulkOperation, error) {
+	op := models.BulkOperation{Type: req.Operation}
+	validate := validator.New()
+	switch req.Operation {
+	case models.BulkSetStatus:
+		if err := validate.Var(req.Value, "required,oneof=pending in_progress completed"); err != nil {
+			return op, fmt.Errorf("Invalid status")
+		}
+		op.Status = req.Value
+	case models.BulkSetPriority:
+		if err := validate.Var(req.Value, "required,oneof=low medium high"); err != nil {
+			return op, fmt.Errorf("Invalid priority")
+		}
+		op.Priority = req.Value
+	case models.BulkAddTag:
+		op.Tag = strings.TrimSpace(req.Value)
+		if err := validate.Var(op.Tag, "required,max=50"); err != nil {
+			return op, fmt.Errorf("Invalid tag")
+		}
+	case models.BulkMoveProject:
+		// Пустое значение убирает задачи из проекта
+		if req.Value == "" {
+			return op, nil
+		}
+		projectID, err := primitive.ObjectIDFromHex(req.Value)
+		if err != nil {
+			return op, fmt.Errorf("Invalid project ID")
+		}
+		if err := checkTaskProject(&models.Task{ProjectID: &projectID}, workspaceID, projectCollection, taskCollection, ctx); err != nil {
+			return op, fmt.Errorf("Invalid project: %v", err)
+		}
+		op.ProjectID = &projectID
+	}
+	return op, nil
+}
+
+func parseBulkFilter(req *bulkFilterRequest) (repositories.TaskFilter, error) {
+	filter := repositories.TaskFilter{
+		Tags:         normalizeTags(req.Tags),
+		MatchAllTags: req.TagsMode == "all",
+		Status:       req.Status,
+		Priority:     req.Priority,
+	}
+	if req.ProjectID != "" {
+		id, err := primitive.ObjectIDFromHex(req.ProjectID)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid project ID")
+		}
+		filter.ProjectID = &id
+	}
+	return filter, nil
+}
+
+// completeBulkTasks выполняет для завершённых задач то же, что и EditTask:
+// завершает родителей и создаёт следующие повторения
+func completeBulkTasks(r *repositories.TaskRepository, taskIDs []primitive.ObjectID, workspaceID primitive.ObjectID, loc *time.Location, pub events.Publisher, ctx context.Context) error {
+	for _, taskID := range taskIDs {
+		if cfg.AutoCompleteParent {
+			task, err := r.GetTask(taskID, workspaceID, ctx)
+			if err != nil {
+				return err
+			}
+			if err := r.CompleteParents(taskID, workspaceID, ctx); err != nil {
+				return err
+			}
+			publishTaskUpdated(pub, r, workspaceID, ctx, task.Ancestors...)
+		}
+		next, err := createNextOccurrence(r, taskID, workspaceID, loc, ctx)
+		if err != nil {
+			return err
+		}
+		if next != nil {
+			next.CalcChecklistProgress()
+			publishTask(pub, events.TaskCreated, next)
+		}
+	}
+	return nil
+}
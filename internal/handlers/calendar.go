@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"task_manager/internal/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+var icalPriorities = map[string]string{"high": "1", "medium": "5", "low": "9"}
+
+var icalTodoStatuses = map[string]string{"pending": "NEEDS-ACTION", "in_progress": "IN-PROCESS", "completed": "COMPLETED"}
+
+// RegenerateCalendarToken выдаёт новую ссылку на календарную подписку; прежняя ссылка перестаёт работать
+func RegenerateCalendarToken(userCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		token, err := utils.NewToken()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		r := repositories.NewUserRepository(userCollection)
+		if _, err := r.SetCalendarToken(user.ID, utils.HashToken(token), ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		// Токен показывается один раз: в базе хранится только его хеш
+		return c.Status(200).JSON(fiber.Map{"url": fmt.Sprintf("%s/api/calendar/%s.ics", c.BaseURL(), token)})
+	}
+}
+
+// RevokeCalendarToken отключает календарную подписку
+func RevokeCalendarToken(userCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		r := repositories.NewUserRepository(userCollection)
+		if _, err := r.SetCalendarToken(user.ID, "", ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Calendar feed disabled"})
+	}
+}
+
+// CalendarFeed отдаёт задачи пользователя со сроком в формате iCalendar.
+// Доступ — по секретному токену в ссылке, без авторизации: календарные приложения не умеют передавать JWT.
+// По умолчанию задачи отдаются событиями (VEVENT), с kind=todo — задачами (VTODO).
+func CalendarFeed(userCollection, taskCollection, workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+
+		kind := c.Query("kind", "event")
+		if kind != "event" && kind != "todo" {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid kind"})
+		}
+		ur := repositories.NewUserRepository(userCollection)
+		user, err := ur.FindUserByCalendarToken(utils.HashToken(c.Params("token")), ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Calendar not found"})
+		}
+
+		wr := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		workspaces, err := wr.GetWorkspaces(user.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		workspaceIDs := make([]primitive.ObjectID, len(workspaces))
+		for i, w := range workspaces {
+			workspaceIDs[i] = w.ID
+		}
+		r := repositories.NewTaskRepository(taskCollection)
+		tasks, err := r.GetCalendarTasks(user.ID, workspaceIDs, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+
+		body, lastModified := renderCalendar(tasks, kind)
+		sum := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		c.Set(fiber.HeaderETag, etag)
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+		if !lastModified.IsZero() {
+			c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+		}
+		if calendarNotModified(c, etag, lastModified) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+		return c.Status(200).Send(body)
+	}
+}
+
+// calendarNotModified проверяет условные заголовки; If-None-Match важнее If-Modified-Since (RFC 9110, 13.2.2).
+// Удаление задачи не сдвигает Last-Modified, поэтому такие изменения ловит только ETag.
+func calendarNotModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	return err == nil && !lastModified.IsZero() && !lastModified.Truncate(time.Second).After(since)
+}
+
+// renderCalendar формирует календарь и возвращает время последнего изменения входящих в него задач
+func renderCalendar(tasks []models.Task, kind string) ([]byte, time.Time) {
+	cal := utils.NewICalendar("Tasks")
+	var lastModified time.Time
+	for _, task := range tasks {
+		modified := task.UpdatedAt
+		if task.CreatedAt.After(modified) {
+			modified = task.CreatedAt
+		}
+		if modified.IsZero() {
+			modified = task.ID.Timestamp()
+		}
+		if modified.After(lastModified) {
+			lastModified = modified
+		}
+
+		component := "VEVENT"
+		if kind == "todo" {
+			component = "VTODO"
+		}
+		cal.Property("BEGIN", component)
+		cal.Property("UID", task.ID.Hex()+"@task_manager")
+		cal.Time("DTSTAMP", modified)
+		if !task.CreatedAt.IsZero() {
+			cal.Time("CREATED", task.CreatedAt)
+		}
+		cal.Time("LAST-MODIFIED", modified)
+		cal.Text("SUMMARY", task.Title)
+		if task.Description != "" {
+			cal.Text("DESCRIPTION", task.Description)
+		}
+		if len(task.Tags) > 0 {
+			cal.List("CATEGORIES", task.Tags)
+		}
+		if priority, ok := icalPriorities[task.Priority]; ok {
+			cal.Property("PRIORITY", priority)
+		}
+
+		due := *task.DueDate
+		// Срок без времени хранится как полночь UTC и показывается событием на весь день
+		allDay := due.UTC().Equal(due.UTC().Truncate(24 * time.Hour))
+		if kind == "todo" {
+			if allDay {
+				cal.Date("DUE", due.UTC())
+			} else {
+				cal.Time("DUE", due)
+			}
+			cal.Property("STATUS", icalTodoStatuses[task.Status])
+			if task.Status == "completed" {
+				cal.Property("PERCENT-COMPLETE", "100")
+			}
+		} else {
+			if allDay {
+				cal.Date("DTSTART", due.UTC())
+				cal.Date("DTEND", due.UTC().AddDate(0, 0, 1))
+			} else {
+				cal.Time("DTSTART", due)
+			}
+			cal.Property("TRANSP", "TRANSPARENT")
+		}
+		cal.Property("END", component)
+	}
+	return cal.Bytes(), lastModified
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"fmt"
+	"task_manager/internal/models"
+	"task_manager/internal/notifier"
+	"task_manager/internal/repositories"
+	"task_manager/internal/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+type commentRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+func CreateComment(commentCollection, taskCollection, userCollection *mongo.Collection, n notifier.Notifier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		req := new(commentRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		tr := repositories.NewTaskRepository(taskCollection)
+		task, err := tr.GetTask(taskID, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		mentions, err := resolveMentions(req.Body, workspace, userCollection, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+
+		comment := &models.Comment{TaskID: taskID, UserID: user.ID, Body: req.Body, Mentions: mentions}
+		r := repositories.NewCommentRepository(commentCollection)
+		if _, err := r.CreateComment(comment, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		notifyCommented(task, comment, user, n, ctx)
+		comment.HTML = utils.RenderMarkdown(comment.Body)
+		return c.Status(201).JSON(fiber.Map{"message": "Comment created successfully", "comment": comment})
+	}
+}
+
+func GetComments(commentCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		tr := repositories.NewTaskRepository(taskCollection)
+		if _, err := tr.GetTask(taskID, workspace.ID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+
+		page, limit := parsePagination(c)
+		r := repositories.NewCommentRepository(commentCollection)
+		comments, total, err := r.GetComments(taskID, page, limit, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		for i := range comments {
+			comments[i].HTML = utils.RenderMarkdown(comments[i].Body)
+		}
+		return c.Status(200).JSON(fiber.Map{"comments": comments, "page": page, "limit": limit, "total": total})
+	}
+}
+
+func EditComment(commentCollection, taskCollection, userCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		commentID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid comment ID"})
+		}
+		req := new(commentRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		r := repositories.NewCommentRepository(commentCollection)
+		existing, err := r.GetComment(commentID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Comment not found"})
+		}
+		tr := repositories.NewTaskRepository(taskCollection)
+		if _, err := tr.GetTask(existing.TaskID, workspace.ID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Comment not found"})
+		}
+		mentions, err := resolveMentions(req.Body, workspace, userCollection, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		comment, err := r.UpdateComment(commentID, user.ID, req.Body, mentions, ctx)
+		if err == repositories.ErrConflict {
+			return c.Status(409).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Comment not found"})
+		}
+		comment.HTML = utils.RenderMarkdown(comment.Body)
+		return c.Status(200).JSON(fiber.Map{"message": "Comment edited successfully", "comment": comment})
+	}
+}
+
+func DeleteComment(commentCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		commentID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid comment ID"})
+		}
+		r := repositories.NewCommentRepository(commentCollection)
+		comment, err := r.GetComment(commentID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Comment not found"})
+		}
+		tr := repositories.NewTaskRepository(taskCollection)
+		if _, err := tr.GetTask(comment.TaskID, workspace.ID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Comment not found"})
+		}
+		// Удалить комментарий может его автор или владелец рабочего пространства
+		if comment.UserID != user.ID && workspace.Role(user.ID) != models.RoleOwner {
+			return c.Status(403).JSON(fiber.Map{"message": "Forbidden"})
+		}
+		if _, err := r.DeleteComment(commentID, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Comment deleted successfully"})
+	}
+}
+
+// notifyCommented уведомляет владельца, исполнителя, наблюдателей задачи и упомянутых пользователей,
+// кроме автора комментария
+func notifyCommented(task *models.Task, comment *models.Comment, author *models.User, n notifier.Notifier, ctx context.Context) {
+	recipients := append([]primitive.ObjectID{task.UserID}, taskFollowers(task)...)
+	notifyUsers(n, append(recipients, comment.Mentions...), author.ID, notifier.Message{
+		TaskID: &task.ID,
+		Type:   models.NotificationTaskCommented,
+		Title:  "New comment",
+		Body:   fmt.Sprintf("%s commented on %q", author.Username, task.Title),
+	}, ctx)
+}
+
+// resolveMentions находит участников рабочего пространства, упомянутых в тексте через @username.
+// Упоминания остальных пользователей игнорируются: уведомление раскрыло бы им название задачи.
+func resolveMentions(body string, workspace *models.Workspace, userCollection *mongo.Collection, ctx context.Context) ([]primitive.ObjectID, error) {
+	ur := repositories.NewUserRepository(userCollection)
+	users, err := ur.FindUsersByUsernames(utils.ExtractMentions(body), ctx)
+	if err != nil {
+		return nil, err
+	}
+	mentions := make([]primitive.ObjectID, 0, len(users))
+	for _, u := range users {
+		if workspace.Role(u.ID) != "" {
+			mentions = append(mentions, u.ID)
+		}
+	}
+	return mentions, nil
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+// csvColumns — колонки CSV-выгрузки; их же понимает импорт без сопоставления колонок
+var csvColumns = []string{"external_id", "title", "description", "status", "priority", "due_date", "tags", "estimate", "project_id", "created_at", "updated_at"}
+
+// taskSource передаёт задачи выгрузки в fn по одной
+type taskSource func(fn func(*models.Task) error) error
+
+// ExportTasks выгружает задачи рабочего пространства в CSV, JSON или NDJSON.
+// Задачи читаются курсором и пишутся в ответ по мере чтения.
+func ExportTasks(collection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workspace := c.Locals("workspace").(*models.Workspace)
+		format := c.Query("format", "json")
+		filter := parseTaskFilter(c)
+		if projectID := c.Query("project_id"); projectID != "" {
+			id, err := primitive.ObjectIDFromHex(projectID)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid project ID"})
+			}
+			filter.ProjectID = &id
+		}
+
+		var write func(w *bufio.Writer, tasks taskSource) error
+		switch format {
+		case "csv":
+			c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+			write = writeTasksCSV
+		case "json":
+			c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+			write = writeTasksJSON
+		case "ndjson":
+			c.Set(fiber.HeaderContentType, "application/x-ndjson")
+			write = writeTasksNDJSON
+		default:
+			return c.Status(400).JSON(fiber.Map{"message": "Unsupported format"})
+		}
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+
+		r := repositories.NewTaskRepository(collection)
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			// Контекст запроса к этому моменту уже завершён; выгрузка прерывается при ошибке записи
+			tasks := taskSource(func(fn func(*models.Task) error) error {
+				return r.ExportTasks(workspace.ID, filter, fn, context.Background())
+			})
+			if err := write(w, tasks); err != nil {
+				log.Error().Err(err).Str("workspace_id", workspace.ID.Hex()).Msg("task export interrupted")
+				return
+			}
+			w.Flush()
+		})
+		return nil
+	}
+}
+
+func writeTasksCSV(w *bufio.Writer, tasks taskSource) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	err := tasks(func(task *models.Task) error {
+		projectID := ""
+		if task.ProjectID != nil {
+			projectID = task.ProjectID.Hex()
+		}
+		dueDate := ""
+		if task.DueDate != nil {
+			dueDate = task.DueDate.UTC().Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			exportExternalID(task),
+			task.Title,
+			task.Description,
+			task.Status,
+			task.Priority,
+			dueDate,
+			strings.Join(task.Tags, ","),
+			strconv.Itoa(task.Estimate),
+			projectID,
+			task.CreatedAt.UTC().Format(time.RFC3339),
+			task.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeTasksJSON(w *bufio.Writer, tasks taskSource) error {
+	if _, err := w.WriteString("["); err != nil {
+		return err
+	}
+	first := true
+	err := tasks(func(task *models.Task) error {
+		if !first {
+			if _, err := w.WriteString(","); err != nil {
+				return err
+			}
+		}
+		first = false
+		task.ExternalID = exportExternalID(task)
+		data, err := json.Marshal(task)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.WriteString("]")
+	return err
+}
+
+func writeTasksNDJSON(w *bufio.Writer, tasks taskSource) error {
+	enc := json.NewEncoder(w)
+	return tasks(func(task *models.Task) error {
+		task.ExternalID = exportExternalID(task)
+		return enc.Encode(task)
+	})
+}
+
+// exportExternalID — идентификатор задачи в выгрузке: по нему повторный импорт обновляет задачу, а не создаёт новую
+func exportExternalID(task *models.Task) string {
+	if task.ExternalID != "" {
+		return task.ExternalID
+	}
+	return task.ID.Hex()
+}
@@ -0,0 +1,515 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+// importMaxRows — сколько строк можно импортировать одним запросом
+const importMaxRows = 1000
+
+// importFields — поля задачи, которые можно импортировать; project — имя проекта,
+// который при необходимости создаётся, checklist — пункты чеклиста
+var importFields = map[string]bool{
+	"external_id": true,
+	"title":       true,
+	"description": true,
+	"status":      true,
+	"priority":    true,
+	"due_date":    true,
+	"tags":        true,
+	"estimate":    true,
+	"project_id":  true,
+	"project":     true,
+	"checklist":   true,
+}
+
+// importRecord — строка файла импорта: значения по именам полей задачи
+type importRecord struct {
+	Row    int
+	Values map[string]interface{}
+	Err    error
+}
+
+// ImportTasks импортирует задачи из CSV, JSON, NDJSON, todo.txt, Markdown-чеклистов и выгрузок Trello и Todoist.
+// Файл передаётся в поле file формы или телом запроса; format, mapping и preview — параметрами формы или запроса.
+// mapping — JSON-объект {"колонка файла": "поле задачи"} для CSV и JSON; колонки с именами полей сопоставляются сами.
+// Строки с external_id, уже импортированные ранее, обновляются, а не создаются заново.
+// С preview=true ничего не сохраняется: в ответе — задачи, которые будут созданы или обновлены, и новые проекты.
+func ImportTasks(collection, projectCollection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		data := c.Body()
+		format, rawMapping := c.Query("format"), c.Query("mapping")
+		preview := c.QueryBool("preview", false)
+		if form, err := c.MultipartForm(); err == nil {
+			if len(form.File["file"]) == 0 {
+				return c.Status(400).JSON(fiber.Map{"message": "No file uploaded"})
+			}
+			file, err := form.File["file"][0].Open()
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid file"})
+			}
+			defer file.Close()
+			if data, err = io.ReadAll(file); err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid file"})
+			}
+			if v := form.Value["format"]; len(v) > 0 {
+				format = v[0]
+			}
+			if v := form.Value["mapping"]; len(v) > 0 {
+				rawMapping = v[0]
+			}
+			if v := form.Value["preview"]; len(v) > 0 {
+				preview = v[0] == "true"
+			}
+		}
+		mapping := map[string]string{}
+		if rawMapping != "" {
+			if err := json.Unmarshal([]byte(rawMapping), &mapping); err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid mapping"})
+			}
+			for _, field := range mapping {
+				if !importFields[field] && field != "" {
+					return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("Unknown task field %q in mapping", field)})
+				}
+			}
+		}
+
+		records, err := parseImportFile(format, data, mapping)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		if len(records) > importMaxRows {
+			return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("File has more than %d rows", importMaxRows)})
+		}
+
+		results, newProjects, err := importRecords(records, collection, projectCollection, user, workspace, preview, pub, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		counts := map[string]int{models.ImportCreated: 0, models.ImportUpdated: 0, models.ImportFailed: 0}
+		for _, result := range results {
+			counts[result.Result]++
+		}
+		return c.Status(200).JSON(fiber.Map{
+			"results": results,
+			"created": counts[models.ImportCreated],
+			"updated": counts[models.ImportUpdated],
+			"failed":  counts[models.ImportFailed],
+			"preview": preview,
+			// В режиме предпросмотра — проекты, которые будут созданы
+			"new_projects": newProjects,
+		})
+	}
+}
+
+// importRecords проверяет строки, сохраняет корректные и возвращает результат по каждой строке в исходном порядке.
+// Проекты, указанные по имени и не найденные среди активных, создаются; при preview только перечисляются.
+func importRecords(records []importRecord, collection, projectCollection *mongo.Collection, user *models.User, workspace *models.Workspace, preview bool, pub events.Publisher, ctx context.Context) ([]models.ImportResult, []string, error) {
+	results := make([]models.ImportResult, len(records))
+	var items []models.ImportItem
+	var rows []int
+	seen := map[string]int{}
+	projects := map[primitive.ObjectID]error{}
+	validate := validator.New()
+	resolver, err := newProjectResolver(projectCollection, collection, user, workspace, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, record := range records {
+		results[i].Row = record.Row
+		fail := func(err error) {
+			results[i].Result, results[i].Error = models.ImportFailed, err.Error()
+		}
+		if record.Err != nil {
+			fail(record.Err)
+			continue
+		}
+		item, projectName, err := recordToImportItem(record.Values, validate)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		results[i].ExternalID = item.Task.ExternalID
+		if id := item.Task.ExternalID; id != "" {
+			if row, ok := seen[id]; ok {
+				fail(fmt.Errorf("duplicate external_id, first seen in row %d", row))
+				continue
+			}
+			seen[id] = record.Row
+		}
+		if projectName != "" {
+			results[i].Project = projectName
+			item.Task.ProjectID = resolver.lookup(projectName)
+			item.Fields = append(item.Fields, "project_id")
+		}
+		if projectID := item.Task.ProjectID; projectID != nil {
+			checked, ok := projects[*projectID]
+			if !ok {
+				checked = checkTaskProject(&item.Task, workspace.ID, projectCollection, collection, ctx)
+				projects[*projectID] = checked
+			}
+			if checked != nil {
+				fail(fmt.Errorf("invalid project: %v", checked))
+				continue
+			}
+		}
+		items = append(items, item)
+		rows = append(rows, i)
+	}
+	if len(items) == 0 {
+		return results, resolver.pending(), nil
+	}
+
+	if !preview {
+		// Проекты создаются только для строк, прошедших проверку
+		for j := range items {
+			if name := results[rows[j]].Project; name != "" && items[j].Task.ProjectID == nil {
+				if items[j].Task.ProjectID, err = resolver.create(name); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	r := repositories.NewTaskRepository(collection)
+	saved, err := r.ImportTasks(workspace.ID, user.ID, items, preview, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for j, result := range saved {
+		result.Row = results[rows[j]].Row
+		result.Project = results[rows[j]].Project
+		results[rows[j]] = result
+		if preview {
+			task := items[j].Task
+			results[rows[j]].Task = &task
+			continue
+		}
+		switch result.Result {
+		case models.ImportCreated:
+			if task, err := r.GetTask(*result.TaskID, workspace.ID, ctx); err == nil {
+				publishTask(pub, events.TaskCreated, task)
+			}
+		case models.ImportUpdated:
+			publishTaskUpdated(pub, r, workspace.ID, ctx, *result.TaskID)
+		}
+	}
+	return results, resolver.pending(), nil
+}
+
+// projectResolver находит активные проекты рабочего пространства по имени без учёта регистра
+// и создаёт недостающие
+type projectResolver struct {
+	r         *repositories.ProjectRepository
+	user      *models.User
+	workspace *models.Workspace
+	byName    map[string]primitive.ObjectID
+	missing   []string
+	ctx       context.Context
+}
+
+func newProjectResolver(projectCollection, taskCollection *mongo.Collection, user *models.User, workspace *models.Workspace, ctx context.Context) (*projectResolver, error) {
+	r := repositories.NewProjectRepository(projectCollection, taskCollection)
+	projects, err := r.GetProjects(workspace.ID, false, ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolver := &projectResolver{r: r, user: user, workspace: workspace, byName: map[string]primitive.ObjectID{}, ctx: ctx}
+	for _, project := range projects {
+		resolver.byName[strings.ToLower(project.Name)] = project.ID
+	}
+	return resolver, nil
+}
+
+// lookup возвращает ID существующего проекта; nil — проект нужно создать
+func (p *projectResolver) lookup(name string) *primitive.ObjectID {
+	if id, ok := p.byName[strings.ToLower(name)]; ok {
+		return &id
+	}
+	for _, missing := range p.missing {
+		if strings.EqualFold(missing, name) {
+			return nil
+		}
+	}
+	p.missing = append(p.missing, name)
+	return nil
+}
+
+func (p *projectResolver) create(name string) (*primitive.ObjectID, error) {
+	if id, ok := p.byName[strings.ToLower(name)]; ok {
+		return &id, nil
+	}
+	project := &models.Project{WorkspaceID: p.workspace.ID, UserID: p.user.ID, Name: name}
+	result, err := p.r.CreateProject(project, p.ctx)
+	if err != nil {
+		return nil, err
+	}
+	id := result.InsertedID.(primitive.ObjectID)
+	p.byName[strings.ToLower(name)] = id
+	return &id, nil
+}
+
+// pending возвращает имена проектов, которых ещё нет в рабочем пространстве
+func (p *projectResolver) pending() []string {
+	names := []string{}
+	for _, name := range p.missing {
+		if _, ok := p.byName[strings.ToLower(name)]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// recordToImportItem собирает задачу из значений строки и проверяет её тегами валидации models.Task.
+// Статус и приоритет, которых нет в строке, подставляются по умолчанию только для новых задач:
+// при обновлении меняются лишь поля из строки.
+func recordToImportItem(values map[string]interface{}, validate *validator.Validate) (models.ImportItem, string, error) {
+	item := models.ImportItem{Task: models.Task{Status: "pending", Priority: "medium", Tags: []string{}}}
+	task := &item.Task
+	projectName := ""
+	for field, value := range values {
+		s := strings.TrimSpace(importString(value))
+		switch field {
+		case "external_id":
+			task.ExternalID = s
+			continue
+		case "title":
+			task.Title = s
+		case "description":
+			task.Description = importString(value)
+		case "status", "priority":
+			// Пустое значение равносильно отсутствию колонки
+			if s == "" {
+				continue
+			}
+			if field == "status" {
+				task.Status = s
+			} else {
+				task.Priority = s
+			}
+		case "due_date":
+			if s != "" {
+				due, err := parseImportTime(s)
+				if err != nil {
+					return item, "", fmt.Errorf("invalid due_date %q", s)
+				}
+				task.DueDate = &due
+			}
+		case "tags":
+			task.Tags = normalizeTags(importStrings(value))
+		case "estimate":
+			if s != "" {
+				estimate, err := strconv.Atoi(s)
+				if err != nil {
+					return item, "", fmt.Errorf("invalid estimate %q", s)
+				}
+				task.Estimate = estimate
+			}
+		case "project_id":
+			if s != "" {
+				id, err := primitive.ObjectIDFromHex(s)
+				if err != nil {
+					return item, "", fmt.Errorf("invalid project_id %q", s)
+				}
+				task.ProjectID = &id
+			}
+		case "project":
+			// Проект по имени подставляется позже, когда станет известен его ID
+			projectName = s
+			continue
+		case "checklist":
+			task.Checklist = importChecklist(value)
+		default:
+			continue
+		}
+		item.Fields = append(item.Fields, field)
+	}
+	if projectName != "" && task.ProjectID != nil {
+		return item, "", fmt.Errorf("project and project_id cannot be used together")
+	}
+	if err := validate.Struct(task); err != nil {
+		return item, "", err
+	}
+	return item, projectName, nil
+}
+
+// parseImportFile разбирает файл в строки импорта с учётом сопоставления колонок.
+// Ошибка разбора отдельной строки CSV или NDJSON попадает в её importRecord, а не прерывает импорт.
+func parseImportFile(format string, data []byte, mapping map[string]string) ([]importRecord, error) {
+	var records []importRecord
+	switch format {
+	case "todotxt":
+		return parseTodoTxt(data), nil
+	case "markdown":
+		return parseMarkdownChecklist(data), nil
+	case "trello":
+		return parseTrello(data)
+	case "todoist":
+		return parseTodoist(data)
+	case "csv":
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV header: %v", err)
+		}
+		// Excel добавляет BOM в начало файла
+		if len(header) > 0 {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+		for row := 1; ; row++ {
+			line, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				records = append(records, importRecord{Row: row, Err: err})
+				continue
+			}
+			values := map[string]interface{}{}
+			for i, column := range header {
+				if i < len(line) {
+					values[column] = line[i]
+				}
+			}
+			records = append(records, importRecord{Row: row, Values: mapImportValues(values, mapping)})
+		}
+	case "json":
+		var objects []map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&objects); err != nil {
+			return nil, fmt.Errorf("invalid JSON: expected an array of objects")
+		}
+		for i, object := range objects {
+			records = append(records, importRecord{Row: i + 1, Values: mapImportValues(object, mapping)})
+		}
+	case "ndjson":
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+		for row := 1; scanner.Scan(); row++ {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var object map[string]interface{}
+			dec := json.NewDecoder(bytes.NewReader(line))
+			dec.UseNumber()
+			if err := dec.Decode(&object); err != nil {
+				records = append(records, importRecord{Row: row, Err: fmt.Errorf("invalid JSON: %v", err)})
+				continue
+			}
+			records = append(records, importRecord{Row: row, Values: mapImportValues(object, mapping)})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported format")
+	}
+	return records, nil
+}
+
+// mapImportValues переименовывает колонки файла в поля задачи; пустое поле в mapping пропускает колонку
+func mapImportValues(values map[string]interface{}, mapping map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+	for column, value := range values {
+		field, ok := mapping[column]
+		if !ok {
+			field = column
+		}
+		if importFields[field] {
+			result[field] = value
+		}
+	}
+	return result
+}
+
+func importString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// importStrings принимает как JSON-массив, так и строку через запятую
+func importStrings(value interface{}) []string {
+	if list, ok := value.([]string); ok {
+		return list
+	}
+	if list, ok := value.([]interface{}); ok {
+		result := make([]string, 0, len(list))
+		for _, v := range list {
+			result = append(result, importString(v))
+		}
+		return result
+	}
+	return strings.Split(importString(value), ",")
+}
+
+// importChecklist принимает пункты из парсеров форматов, JSON-массив строк или объектов {"text", "done"}
+// и строку с пунктами на отдельных строках
+func importChecklist(value interface{}) []models.ChecklistItem {
+	var items []models.ChecklistItem
+	switch v := value.(type) {
+	case []models.ChecklistItem:
+		items = v
+	case []interface{}:
+		for _, entry := range v {
+			if object, ok := entry.(map[string]interface{}); ok {
+				done, _ := object["done"].(bool)
+				items = append(items, models.ChecklistItem{Text: importString(object["text"]), Done: done})
+			} else {
+				items = append(items, models.ChecklistItem{Text: importString(entry)})
+			}
+		}
+	default:
+		for _, line := range strings.Split(importString(value), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				items = append(items, models.ChecklistItem{Text: line})
+			}
+		}
+	}
+	for i := range items {
+		items[i].ID = primitive.NewObjectID()
+		items[i].Text = strings.TrimSpace(items[i].Text)
+	}
+	return items
+}
+
+// parseImportTime понимает RFC 3339, время без часового пояса (считается UTC) и дату без времени
+func parseImportTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05", s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
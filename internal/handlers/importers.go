@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"task_manager/internal/models"
+)
+
+// Разбор форматов других менеджеров задач. Каждый парсер возвращает строки импорта
+// с полями задачи; project — имя проекта, checklist — []models.ChecklistItem.
+
+var (
+	todoTxtDate      = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	todoTxtPriority  = regexp.MustCompile(`^\(([A-Z])\)$`)
+	mdChecklistItem  = regexp.MustCompile(`^(\s*)[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	mdImportHeading  = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+	mdHashtag        = regexp.MustCompile(`(^|\s)#([\p{L}\d_-]+)`)
+	trelloDoneList   = regexp.MustCompile(`(?i)\b(done|complete|completed|finished)\b|готов|сделан|выполнен`)
+	trelloActiveList = regexp.MustCompile(`(?i)\b(doing|in progress|progress|wip)\b|в работе|в процессе`)
+)
+
+// parseTodoTxt разбирает формат todo.txt: "x" — выполнено, (A)–(Z) — приоритет,
+// +project — проект (остальные +project становятся тегами), @context — тег, due:ГГГГ-ММ-ДД — срок
+func parseTodoTxt(data []byte) []importRecord {
+	var records []importRecord
+	externalID := contentExternalIDs("todotxt")
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for row := 1; scanner.Scan(); row++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		values := map[string]interface{}{}
+		if fields[0] == "x" {
+			values["status"] = "completed"
+			fields = fields[1:]
+		}
+		if len(fields) > 0 {
+			if m := todoTxtPriority.FindStringSubmatch(fields[0]); m != nil {
+				values["priority"] = todoTxtPriorityOf(m[1])
+				fields = fields[1:]
+			}
+		}
+		// Даты завершения и создания идут перед текстом задачи
+		for i := 0; i < 2 && len(fields) > 0 && todoTxtDate.MatchString(fields[0]); i++ {
+			fields = fields[1:]
+		}
+
+		var title, tags []string
+		project := ""
+		for _, field := range fields {
+			switch {
+			case len(field) > 1 && field[0] == '+':
+				if project == "" {
+					project = field[1:]
+				} else {
+					tags = append(tags, field[1:])
+				}
+			case len(field) > 1 && field[0] == '@':
+				tags = append(tags, field[1:])
+			case strings.HasPrefix(field, "due:") && len(field) > 4:
+				values["due_date"] = field[4:]
+			case strings.HasPrefix(field, "pri:") && len(field) == 5:
+				// Так некоторые клиенты сохраняют приоритет выполненной задачи
+				values["priority"] = todoTxtPriorityOf(strings.ToUpper(field[4:]))
+			default:
+				title = append(title, field)
+			}
+		}
+		values["title"] = strings.Join(title, " ")
+		values["tags"] = tags
+		if project != "" {
+			values["project"] = project
+		}
+		values["external_id"] = externalID(project, values["title"].(string))
+		records = append(records, importRecord{Row: row, Values: values})
+	}
+	return records
+}
+
+func todoTxtPriorityOf(letter string) string {
+	switch letter {
+	case "A":
+		return "high"
+	case "B":
+		return "medium"
+	}
+	return "low"
+}
+
+// parseMarkdownChecklist разбирает списки "- [ ]" и "- [x]". Заголовок задаёт проект для пунктов под ним,
+// вложенные пункты становятся чеклистом задачи, #слова — тегами.
+func parseMarkdownChecklist(data []byte) []importRecord {
+	var records []importRecord
+	externalID := contentExternalIDs("markdown")
+	project := ""
+	indent := -1
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if m := mdImportHeading.FindStringSubmatch(line); m != nil {
+			project = strings.TrimSpace(m[1])
+			indent = -1
+			continue
+		}
+		m := mdChecklistItem.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		depth := len(strings.ReplaceAll(m[1], "\t", "    "))
+		done := m[2] != " "
+		if indent >= 0 && depth > indent && len(records) > 0 {
+			last := records[len(records)-1].Values
+			checklist, _ := last["checklist"].([]models.ChecklistItem)
+			last["checklist"] = append(checklist, models.ChecklistItem{Text: strings.TrimSpace(m[3]), Done: done})
+			continue
+		}
+		indent = depth
+
+		var tags []string
+		title := mdHashtag.ReplaceAllStringFunc(m[3], func(s string) string {
+			tags = append(tags, strings.TrimSpace(s)[1:])
+			return ""
+		})
+		title = strings.Join(strings.Fields(title), " ")
+		values := map[string]interface{}{"title": title, "tags": tags}
+		if done {
+			values["status"] = "completed"
+		}
+		if project != "" {
+			values["project"] = project
+		}
+		values["external_id"] = externalID(project, title)
+		records = append(records, importRecord{Row: row, Values: values})
+	}
+	return records
+}
+
+// contentExternalIDs возвращает генератор external_id для форматов без собственных идентификаторов,
+// чтобы повторный импорт того же файла обновлял задачи, а не создавал их заново.
+// Повторы одной задачи в проекте различаются номером повтора; у первой он не учитывается.
+func contentExternalIDs(source string) func(project, title string) string {
+	seen := make(map[string]int)
+	return func(project, title string) string {
+		key := project + "\x00" + strings.ToLower(title)
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key += "\x00" + strconv.Itoa(n)
+		}
+		sum := sha256.Sum256([]byte(key))
+		return source + ":" + hex.EncodeToString(sum[:8])
+	}
+}
+
+type trelloBoard struct {
+	Name  string `json:"name"`
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		ID          string  `json:"id"`
+		Name        string  `json:"name"`
+		Desc        string  `json:"desc"`
+		IDList      string  `json:"idList"`
+		Due         *string `json:"due"`
+		DueComplete bool    `json:"dueComplete"`
+		Closed      bool    `json:"closed"`
+		Labels      []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+	Checklists []struct {
+		IDCard     string `json:"idCard"`
+		CheckItems []struct {
+			Name  string  `json:"name"`
+			State string  `json:"state"`
+			Pos   float64 `json:"pos"`
+		} `json:"checkItems"`
+	} `json:"checklists"`
+}
+
+// parseTrello разбирает JSON-экспорт доски Trello. Доска становится проектом, метки — тегами,
+// статус определяется по названию списка. Архивные карточки и списки пропускаются.
+func parseTrello(data []byte) ([]importRecord, error) {
+	var board trelloBoard
+	if err := json.Unmarshal(data, &board); err != nil {
+		return nil, fmt.Errorf("invalid Trello export: %v", err)
+	}
+	lists := map[string]string{}
+	closedLists := map[string]bool{}
+	for _, list := range board.Lists {
+		lists[list.ID] = list.Name
+		closedLists[list.ID] = list.Closed
+	}
+	checklists := map[string][]models.ChecklistItem{}
+	for _, checklist := range board.Checklists {
+		items := checklist.CheckItems
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Pos < items[j].Pos })
+		for _, item := range items {
+			checklists[checklist.IDCard] = append(checklists[checklist.IDCard], models.ChecklistItem{Text: item.Name, Done: item.State == "complete"})
+		}
+	}
+
+	var records []importRecord
+	for i, card := range board.Cards {
+		if card.Closed || closedLists[card.IDList] {
+			continue
+		}
+		status := "pending"
+		switch list := lists[card.IDList]; {
+		case card.DueComplete || trelloDoneList.MatchString(list):
+			status = "completed"
+		case trelloActiveList.MatchString(list):
+			status = "in_progress"
+		}
+		var tags []string
+		for _, label := range card.Labels {
+			if label.Name != "" {
+				tags = append(tags, label.Name)
+			} else if label.Color != "" {
+				tags = append(tags, label.Color)
+			}
+		}
+		values := map[string]interface{}{
+			"external_id": "trello:" + card.ID,
+			"title":       card.Name,
+			"description": card.Desc,
+			"status":      status,
+			"tags":        tags,
+		}
+		if card.Due != nil {
+			values["due_date"] = *card.Due
+		}
+		if board.Name != "" {
+			values["project"] = board.Name
+		}
+		if checklist, ok := checklists[card.ID]; ok {
+			values["checklist"] = checklist
+		}
+		records = append(records, importRecord{Row: i + 1, Values: values})
+	}
+	return records, nil
+}
+
+type todoistTask struct {
+	ID          interface{} `json:"id"`
+	Content     string      `json:"content"`
+	Description string      `json:"description"`
+	ProjectID   interface{} `json:"project_id"`
+	Priority    int         `json:"priority"`
+	Labels      []string    `json:"labels"`
+	Checked     bool        `json:"checked"`
+	IsCompleted bool        `json:"is_completed"`
+	IsDeleted   bool        `json:"is_deleted"`
+	Due         *struct {
+		Date     string `json:"date"`
+		Datetime string `json:"datetime"`
+	} `json:"due"`
+}
+
+// parseTodoist разбирает выгрузку Todoist: ответ Sync API ({"projects": [...], "items": [...]})
+// или список задач REST API. Приоритет Todoist 4 — самый высокий, 1 — без приоритета.
+func parseTodoist(data []byte) ([]importRecord, error) {
+	var export struct {
+		Projects []struct {
+			ID   interface{} `json:"id"`
+			Name string      `json:"name"`
+		} `json:"projects"`
+		Items []todoistTask `json:"items"`
+	}
+	var tasks []todoistTask
+	// Старые выгрузки содержат числовые ID, которые не должны превращаться в float64
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = dec.Decode(&tasks)
+	} else {
+		err = dec.Decode(&export)
+		tasks = export.Items
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid Todoist export: %v", err)
+	}
+	projects := map[string]string{}
+	for _, project := range export.Projects {
+		projects[importString(project.ID)] = project.Name
+	}
+
+	var records []importRecord
+	for i, task := range tasks {
+		if task.IsDeleted {
+			continue
+		}
+		values := map[string]interface{}{
+			"external_id": "todoist:" + importString(task.ID),
+			"title":       task.Content,
+			"description": task.Description,
+			"tags":        task.Labels,
+		}
+		switch task.Priority {
+		case 4:
+			values["priority"] = "high"
+		case 3:
+			values["priority"] = "medium"
+		case 2:
+			values["priority"] = "low"
+		}
+		if task.Checked || task.IsCompleted {
+			values["status"] = "completed"
+		}
+		if task.Due != nil {
+			if task.Due.Datetime != "" {
+				values["due_date"] = task.Due.Datetime
+			} else {
+				values["due_date"] = task.Due.Date
+			}
+		}
+		if name := projects[importString(task.ProjectID)]; name != "" {
+			values["project"] = name
+		}
+		records = append(records, importRecord{Row: i + 1, Values: values})
+	}
+	return records, nil
+}
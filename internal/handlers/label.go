@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+type renameLabelRequest struct {
+	Name  string `json:"name" validate:"required,max=50"`
+	Color string `json:"color" validate:"omitempty,hexcolor"`
+}
+
+type mergeLabelsRequest struct {
+	TargetID  string   `json:"target_id" validate:"required"`
+	SourceIDs []string `json:"source_ids" validate:"required,min=1"`
+}
+
+func CreateLabel(labelCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+		label := new(models.Label)
+		if err := c.BodyParser(label); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		label.Name = strings.TrimSpace(label.Name)
+
+		validate := validator.New()
+		if err := validate.Struct(label); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		label.WorkspaceID = workspace.ID
+		label.UserID = user.ID
+		r := repositories.NewLabelRepository(labelCollection, taskCollection)
+		result, err := r.CreateLabel(label, ctx)
+		if err == repositories.ErrLabelExists {
+			return c.Status(409).JSON(fiber.Map{"message": "Label already exists"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(201).JSON(fiber.Map{"message": "Label created successfully", "id": result.InsertedID})
+	}
+}
+
+func GetLabels(labelCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+		r := repositories.NewLabelRepository(labelCollection, taskCollection)
+		labels, err := r.GetLabels(workspace.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"labels": labels})
+	}
+}
+
+func RenameLabel(labelCollection, taskCollection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		labelID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid label ID"})
+		}
+		req := new(renameLabelRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		req.Name = strings.TrimSpace(req.Name)
+
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		r := repositories.NewLabelRepository(labelCollection, taskCollection)
+		label, changed, err := r.RenameLabel(workspace.ID, labelID, req.Name, req.Color, ctx)
+		if err == repositories.ErrLabelExists {
+			return c.Status(409).JSON(fiber.Map{"message": "Label with this name already exists, use merge instead"})
+		}
+		if err == repositories.ErrLabelNotFound {
+			return c.Status(404).JSON(fiber.Map{"message": "Label not found"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTaskUpdated(pub, repositories.NewTaskRepository(taskCollection), workspace.ID, ctx, changed...)
+		return c.Status(200).JSON(fiber.Map{"message": "Label renamed successfully", "label": label})
+	}
+}
+
+func MergeLabels(labelCollection, taskCollection, undoCollection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		req := new(mergeLabelsRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		targetID, err := primitive.ObjectIDFromHex(req.TargetID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid label ID"})
+		}
+		sourceIDs, err := parseObjectIDs(req.SourceIDs)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid label ID"})
+		}
+
+		r := repositories.NewLabelRepository(labelCollection, taskCollection)
+		label, undo, changed, err := r.MergeLabels(workspace.ID, targetID, sourceIDs, ctx)
+		if err == repositories.ErrLabelNotFound {
+			return c.Status(404).JSON(fiber.Map{"message": "Label not found"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTaskUpdated(pub, repositories.NewTaskRepository(taskCollection), workspace.ID, ctx, changed...)
+		response := fiber.Map{"message": "Labels merged successfully", "label": label}
+		return c.Status(200).JSON(issueUndo(response, undoCollection, user, workspace.ID, models.UndoLabelMerge, undo, ctx))
+	}
+}
+
+func DeleteLabel(labelCollection, taskCollection, undoCollection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		labelID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid label ID"})
+		}
+		r := repositories.NewLabelRepository(labelCollection, taskCollection)
+		undo, changed, err := r.DeleteLabel(workspace.ID, labelID, ctx)
+		if err == repositories.ErrLabelNotFound {
+			return c.Status(404).JSON(fiber.Map{"message": "Label not found"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTaskUpdated(pub, repositories.NewTaskRepository(taskCollection), workspace.ID, ctx, changed...)
+		response := fiber.Map{"message": "Label deleted successfully"}
+		return c.Status(200).JSON(issueUndo(response, undoCollection, user, workspace.ID, models.UndoLabelDelete, undo, ctx))
+	}
+}
+
+func AutocompleteTags(labelCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		limit := c.QueryInt("limit", 10)
+		if limit <= 0 || limit > 50 {
+			limit = 10
+		}
+		r := repositories.NewLabelRepository(labelCollection, taskCollection)
+		tags, err := r.TagUsage(workspace.ID, strings.TrimSpace(c.Query("q")), limit, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"tags": tags})
+	}
+}
+
+// normalizeTags убирает пробелы, пустые значения и дубликаты
+func normalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+func parseObjectIDs(hexes []string) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(hexes))
+	for _, hex := range hexes {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
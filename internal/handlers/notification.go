@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+func GetNotifications(notificationCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		page, limit := parsePagination(c)
+		r := repositories.NewNotificationRepository(notificationCollection)
+		notifications, total, err := r.GetNotifications(user.ID, c.QueryBool("unread"), page, limit, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		unread, err := r.UnreadCount(user.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{
+			"notifications": notifications,
+			"unread_count":  unread,
+			"page":          page,
+			"limit":         limit,
+			"total":         total,
+		})
+	}
+}
+
+func MarkNotificationRead(notificationCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		notificationID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid notification ID"})
+		}
+		r := repositories.NewNotificationRepository(notificationCollection)
+		if err := r.MarkRead(user.ID, notificationID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Notification not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Notification marked as read"})
+	}
+}
+
+func MarkAllNotificationsRead(notificationCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		r := repositories.NewNotificationRepository(notificationCollection)
+		result, err := r.MarkAllRead(user.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Notifications marked as read", "updated": result.ModifiedCount})
+	}
+}
+
+// GetNotificationPreferences возвращает действующие настройки для всех типов уведомлений
+func GetNotificationPreferences(userCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		ur := repositories.NewUserRepository(userCollection)
+		current, err := ur.GetUser(user.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "User not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"preferences": effectivePreferences(current)})
+	}
+}
+
+func UpdateNotificationPreferences(userCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		prefs := map[string]models.NotificationPreference{}
+		if err := c.BodyParser(&prefs); err != nil || len(prefs) == 0 {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		for notificationType := range prefs {
+			if !slices.Contains(models.NotificationTypes, notificationType) {
+				return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("Unknown notification type: %s", notificationType)})
+			}
+		}
+
+		ur := repositories.NewUserRepository(userCollection)
+		if _, err := ur.UpdateNotificationPreferences(user.ID, prefs, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		current, err := ur.GetUser(user.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "User not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Preferences updated successfully", "preferences": effectivePreferences(current)})
+	}
+}
+
+func effectivePreferences(user *models.User) map[string]models.NotificationPreference {
+	prefs := make(map[string]models.NotificationPreference, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		prefs[notificationType] = user.NotificationPreference(notificationType)
+	}
+	return prefs
+}
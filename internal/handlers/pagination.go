@@ -0,0 +1,21 @@
+package handlers
+
+import "github.com/gofiber/fiber/v2"
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// parsePagination читает параметры page и limit из строки запроса
+func parsePagination(c *fiber.Ctx) (int, int) {
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", defaultPageSize)
+	if limit < 1 || limit > maxPageSize {
+		limit = defaultPageSize
+	}
+	return page, limit
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"fmt"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+func CreateProject(projectCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+		project := new(models.Project)
+		if err := c.BodyParser(project); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(project); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		project.WorkspaceID = workspace.ID
+		project.UserID = user.ID
+		project.Archived = false
+		r := repositories.NewProjectRepository(projectCollection, taskCollection)
+		result, err := r.CreateProject(project, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(201).JSON(fiber.Map{"message": "Project created successfully", "id": result.InsertedID})
+	}
+}
+
+func GetProjects(projectCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+		r := repositories.NewProjectRepository(projectCollection, taskCollection)
+		projects, err := r.GetProjects(workspace.ID, c.QueryBool("archived", false), ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"projects": projects})
+	}
+}
+
+func GetProject(projectCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		projectID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid project ID"})
+		}
+		r := repositories.NewProjectRepository(projectCollection, taskCollection)
+		project, err := r.GetProject(workspace.ID, projectID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Project not found"})
+		}
+		stats, err := r.StatusCounts(workspace.ID, &projectID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		counts := models.ProjectStats{ProjectID: projectID, StatusCounts: map[string]int{}}
+		if len(stats) > 0 {
+			counts = stats[0]
+		}
+		return c.Status(200).JSON(fiber.Map{"project": project, "stats": counts})
+	}
+}
+
+func EditProject(projectCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		projectID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid project ID"})
+		}
+		project := new(models.Project)
+		if err := c.BodyParser(project); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(project); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		project.ID = projectID
+		project.WorkspaceID = workspace.ID
+		project.UserID = user.ID
+		r := repositories.NewProjectRepository(projectCollection, taskCollection)
+		result, err := r.UpdateProject(project, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if result.MatchedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Project not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Project edited successfully"})
+	}
+}
+
+func ArchiveProject(projectCollection, taskCollection *mongo.Collection, archived bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		projectID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid project ID"})
+		}
+		r := repositories.NewProjectRepository(projectCollection, taskCollection)
+		if _, err := r.SetArchived(workspace.ID, projectID, archived, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Project not found"})
+		}
+		if archived {
+			return c.Status(200).JSON(fiber.Map{"message": "Project archived successfully"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Project unarchived successfully"})
+	}
+}
+
+func GetProjectTasks(projectCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		projectID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid project ID"})
+		}
+		pr := repositories.NewProjectRepository(projectCollection, taskCollection)
+		if _, err := pr.GetProject(workspace.ID, projectID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Project not found"})
+		}
+
+		filter := parseTaskFilter(c)
+		filter.ProjectID = &projectID
+		r := repositories.NewTaskRepository(taskCollection)
+		tasks, err := r.GetTasks(workspace.ID, filter, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"tasks": tasks})
+	}
+}
+
+func GetProjectStats(projectCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+		r := repositories.NewProjectRepository(projectCollection, taskCollection)
+		stats, err := r.StatusCounts(workspace.ID, nil, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"stats": stats})
+	}
+}
+
+// checkTaskProject проверяет, что проект задачи существует, принадлежит рабочему пространству и не в архиве
+func checkTaskProject(task *models.Task, workspaceID primitive.ObjectID, projectCollection, taskCollection *mongo.Collection, ctx context.Context) error {
+	if task.ProjectID == nil || task.ProjectID.IsZero() {
+		task.ProjectID = nil
+		return nil
+	}
+	r := repositories.NewProjectRepository(projectCollection, taskCollection)
+	project, err := r.GetProject(workspaceID, *task.ProjectID, ctx)
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		return fmt.Errorf("project is archived")
+	}
+	return nil
+}
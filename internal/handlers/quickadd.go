@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"task_manager/internal/utils"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+type quickAddRequest struct {
+	Text      string              `json:"text" validate:"required,max=1000"`
+	ProjectID *primitive.ObjectID `json:"project_id"`
+	Preview   bool                `json:"preview"`
+	// Now позволяет клиенту зафиксировать момент, от которого считаются "завтра" и "через час"
+	Now *time.Time `json:"now"`
+}
+
+// QuickAddTask создаёт задачу из строки вида "Deploy billing fix tomorrow 5pm !high #backend".
+// Даты считаются в часовом поясе пользователя. С preview=true задача не создаётся:
+// клиент получает разбор строки, чтобы показать его пользователю для подтверждения.
+func QuickAddTask(collection, projectCollection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		req := new(quickAddRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		now := time.Now()
+		if req.Now != nil {
+			now = *req.Now
+		}
+		parsed := utils.ParseQuickAdd(req.Text, now.In(user.Location()))
+		if parsed.Title == "" {
+			return c.Status(400).JSON(fiber.Map{"message": "Task title is empty", "interpretation": parsed})
+		}
+
+		task := &models.Task{
+			WorkspaceID: workspace.ID,
+			UserID:      user.ID,
+			ProjectID:   req.ProjectID,
+			Title:       parsed.Title,
+			Status:      "pending",
+			Priority:    parsed.Priority,
+			DueDate:     parsed.DueDate,
+			Tags:        normalizeTags(parsed.Tags),
+		}
+		if task.Priority == "" {
+			task.Priority = "medium"
+		}
+		if err := validate.Struct(task); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error(), "interpretation": parsed})
+		}
+		if err := checkTaskProject(task, workspace.ID, projectCollection, collection, ctx); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("Invalid project: %v", err)})
+		}
+		if req.Preview {
+			return c.Status(200).JSON(fiber.Map{"interpretation": parsed, "task": task})
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		result, err := r.CreateTask(task, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		task.ID = result.InsertedID.(primitive.ObjectID)
+		publishTask(pub, events.TaskCreated, task)
+		return c.Status(201).JSON(fiber.Map{"message": "Task created successfully", "interpretation": parsed, "task": task})
+	}
+}
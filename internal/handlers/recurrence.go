@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"task_manager/internal/utils"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+const maxPreviewOccurrences = 100
+
+type recurrencePreviewRequest struct {
+	Rule  string    `json:"rule" validate:"required"`
+	Start time.Time `json:"start" validate:"required"`
+	Count int       `json:"count" validate:"omitempty,min=1,max=100"`
+}
+
+func PreviewTaskOccurrences(collection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		r := repositories.NewTaskRepository(collection)
+		task, err := r.GetTask(taskID, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		if task.Recurrence == "" || task.RecurrenceStart == nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Task is not recurring"})
+		}
+		rule, err := utils.ParseRRule(task.Recurrence)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		count := previewCount(c.QueryInt("count", 5))
+		dates := rule.Occurrences(*task.RecurrenceStart, user.Location(), task.Occurrence+count)
+		if len(dates) > task.Occurrence {
+			dates = dates[task.Occurrence:]
+		} else {
+			dates = []time.Time{}
+		}
+		return c.Status(200).JSON(fiber.Map{"occurrences": dates})
+	}
+}
+
+func PreviewRecurrence(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+	req := new(recurrencePreviewRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+	}
+	rule, err := utils.ParseRRule(req.Rule)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+	}
+	dates := rule.Occurrences(req.Start, user.Location(), previewCount(req.Count))
+	return c.Status(200).JSON(fiber.Map{"occurrences": dates})
+}
+
+// prepareRecurrence проверяет правило повторения и заполняет начало серии.
+// При редактировании с тем же правилом серия продолжается.
+func prepareRecurrence(task *models.Task, existing *models.Task) error {
+	if task.Recurrence == "" {
+		task.RecurrenceStart, task.Occurrence = nil, 0
+		return nil
+	}
+	if _, err := utils.ParseRRule(task.Recurrence); err != nil {
+		return fmt.Errorf("invalid recurrence: %v", err)
+	}
+	if task.DueDate == nil {
+		return fmt.Errorf("recurring task requires due_date")
+	}
+	if existing != nil && existing.Recurrence == task.Recurrence && existing.RecurrenceStart != nil {
+		task.RecurrenceStart, task.Occurrence = existing.RecurrenceStart, existing.Occurrence
+		return nil
+	}
+	start := *task.DueDate
+	task.RecurrenceStart, task.Occurrence = &start, 1
+	return nil
+}
+
+// createNextOccurrence создаёт следующее повторение завершённой задачи; nil — серия закончилась
+func createNextOccurrence(r *repositories.TaskRepository, taskID, workspaceID primitive.ObjectID, loc *time.Location, ctx context.Context) (*models.Task, error) {
+	task, err := r.GetTask(taskID, workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if task.Recurrence == "" || task.RecurrenceStart == nil {
+		return nil, nil
+	}
+	rule, err := utils.ParseRRule(task.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	dates := rule.Occurrences(*task.RecurrenceStart, loc, task.Occurrence+1)
+	if len(dates) <= task.Occurrence {
+		return nil, nil
+	}
+	return r.CreateNextOccurrence(task, dates[task.Occurrence], ctx)
+}
+
+func previewCount(count int) int {
+	if count < 1 {
+		return 5
+	}
+	if count > maxPreviewOccurrences {
+		return maxPreviewOccurrences
+	}
+	return count
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/net/context"
+)
+
+// streamKeepAlive — период служебных сообщений, по которым обнаруживается отключение клиента
+const streamKeepAlive = 15 * time.Second
+
+// StreamTasks отдаёт события задач пользователя через Server-Sent Events.
+// Пропущенные события досылаются по заголовку Last-Event-ID (или параметру last_event_id);
+// если их уже нет в истории, клиент получает событие reset и должен заново загрузить задачи.
+func StreamTasks(bus *events.Bus) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workspace := c.Locals("workspace").(*models.Workspace)
+		lastEventID := c.Get("Last-Event-ID", c.Query("last_event_id"))
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		ch, backlog, ok, unsubscribe := bus.Subscribe(workspace.ID, lastEventID)
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer unsubscribe()
+			if !ok {
+				fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+			}
+			for _, e := range backlog {
+				if err := writeSSE(w, e); err != nil {
+					return
+				}
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			ticker := time.NewTicker(streamKeepAlive)
+			defer ticker.Stop()
+			for {
+				select {
+				case e, open := <-ch:
+					if !open {
+						return
+					}
+					if err := writeSSE(w, e); err != nil {
+						return
+					}
+				case <-ticker.C:
+					fmt.Fprint(w, ": ping\n\n")
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		})
+		return nil
+	}
+}
+
+func writeSSE(w *bufio.Writer, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// UpgradeWebSocket пропускает дальше только запросы на установку WebSocket-соединения
+func UpgradeWebSocket(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(426).JSON(fiber.Map{"message": "Upgrade required"})
+	}
+	return c.Next()
+}
+
+// TaskSocket отдаёт те же события, что и StreamTasks, через WebSocket.
+// Последний полученный ID передаётся в параметре last_event_id.
+func TaskSocket(bus *events.Bus) fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		workspace, _ := conn.Locals("workspace").(*models.Workspace)
+		if workspace == nil {
+			return
+		}
+		ch, backlog, ok, unsubscribe := bus.Subscribe(workspace.ID, conn.Query("last_event_id"))
+		defer unsubscribe()
+
+		// Чтение нужно только для обработки закрытия соединения клиентом
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		if !ok {
+			if err := conn.WriteJSON(fiber.Map{"type": "reset"}); err != nil {
+				return
+			}
+		}
+		for _, e := range backlog {
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		}
+
+		ticker := time.NewTicker(streamKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-closed:
+				return
+			case e, open := <-ch:
+				if !open {
+					return
+				}
+				if err := conn.WriteJSON(e); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamKeepAlive)); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// publishTask публикует событие с текущим состоянием задачи
+func publishTask(pub events.Publisher, eventType string, task *models.Task) {
+	pub.Publish(events.Event{Type: eventType, WorkspaceID: task.WorkspaceID, TaskID: task.ID, Task: task})
+}
+
+// publishTaskUpdated перечитывает задачи и публикует их обновлённое состояние
+func publishTaskUpdated(pub events.Publisher, r *repositories.TaskRepository, workspaceID primitive.ObjectID, ctx context.Context, taskIDs ...primitive.ObjectID) {
+	for _, taskID := range taskIDs {
+		task, err := r.GetTask(taskID, workspaceID, ctx)
+		if err != nil {
+			log.Error().Err(err).Str("task_id", taskID.Hex()).Msg("failed to load task for event")
+			continue
+		}
+		publishTask(pub, events.TaskUpdated, task)
+	}
+}
+
+func publishTasksDeleted(pub events.Publisher, workspaceID primitive.ObjectID, taskIDs []primitive.ObjectID) {
+	for _, taskID := range taskIDs {
+		pub.Publish(events.Event{Type: events.TaskDeleted, WorkspaceID: workspaceID, TaskID: taskID})
+	}
+}
@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"fmt"
+	"strings"
+	"task_manager/internal/events"
 	"task_manager/internal/models"
+	"task_manager/internal/notifier"
 	"task_manager/internal/repositories"
+	"task_manager/internal/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -12,13 +16,31 @@ import (
 	"golang.org/x/net/context"
 )
 
-func GetTasks(collection *mongo.Collection) fiber.Handler {
+func GetTasks(collection, projectCollection *mongo.Collection) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
 		defer cancel()
-		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		filter := parseTaskFilter(c)
+		if projectID := c.Query("project_id"); projectID != "" {
+			id, err := primitive.ObjectIDFromHex(projectID)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid project ID"})
+			}
+			filter.ProjectID = &id
+		} else if !c.QueryBool("include_archived", false) {
+			// Задачи архивных проектов скрыты из общего списка
+			pr := repositories.NewProjectRepository(projectCollection, collection)
+			archived, err := pr.ArchivedProjectIDs(workspace.ID, ctx)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+			}
+			filter.ExcludeProjectIDs = archived
+		}
+
 		r := repositories.NewTaskRepository(collection)
-		tasks, err := r.GetTasks(user, ctx)
+		tasks, err := r.GetTasks(workspace.ID, filter, ctx)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
 		}
@@ -26,11 +48,12 @@ func GetTasks(collection *mongo.Collection) fiber.Handler {
 	}
 }
 
-func CreateTask(collection *mongo.Collection) fiber.Handler {
+func CreateTask(collection, projectCollection *mongo.Collection, n notifier.Notifier, pub events.Publisher) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
 		defer cancel()
 		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
 		task := new(models.Task)
 		if err := c.BodyParser(task); err != nil {
 			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
@@ -40,21 +63,51 @@ func CreateTask(collection *mongo.Collection) fiber.Handler {
 		if err := validate.Struct(task); err != nil {
 			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
 		}
+		task.WorkspaceID = workspace.ID
 		task.UserID = user.ID
+		task.Tags = normalizeTags(task.Tags)
+		task.ParentID, task.Ancestors = nil, nil
+		task.BlockedBy, task.Watchers = nil, nil
+		task.ExternalID = ""
+		if task.AssigneeID != nil && workspace.Role(*task.AssigneeID) == "" {
+			return c.Status(400).JSON(fiber.Map{"message": "Assignee is not a member of the workspace"})
+		}
+		for i := range task.Checklist {
+			task.Checklist[i].ID = primitive.NewObjectID()
+		}
+		if err := prepareRecurrence(task, nil); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err := checkTaskProject(task, workspace.ID, projectCollection, collection, ctx); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("Invalid project: %v", err)})
+		}
 		r := repositories.NewTaskRepository(collection)
-		if _, err := r.CreateTask(task, ctx); err != nil {
+		result, err := r.CreateTask(task, ctx)
+		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
 		}
+		task.ID = result.InsertedID.(primitive.ObjectID)
+		task.CalcChecklistProgress()
+		publishTask(pub, events.TaskCreated, task)
+		if task.AssigneeID != nil {
+			notifyUsers(n, []primitive.ObjectID{*task.AssigneeID}, user.ID, notifier.Message{
+				TaskID: &task.ID,
+				Type:   models.NotificationTaskAssigned,
+				Title:  "Task assigned",
+				Body:   fmt.Sprintf("%s assigned %q to you", user.Username, task.Title),
+			}, ctx)
+		}
 		return c.Status(200).JSON(fiber.Map{"message": "Task created successfully"})
 	}
 }
 
-func EditTask(collection *mongo.Collection) fiber.Handler {
+func EditTask(collection, projectCollection *mongo.Collection, n notifier.Notifier, pub events.Publisher) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
 		defer cancel()
 
 		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
 		task := new(models.Task)
 		if err := c.BodyParser(task); err != nil {
 			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
@@ -65,29 +118,427 @@ func EditTask(collection *mongo.Collection) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
 		}
 
+		task.WorkspaceID = workspace.ID
 		task.UserID = user.ID
+		task.Tags = normalizeTags(task.Tags)
+		if err := checkTaskProject(task, workspace.ID, projectCollection, collection, ctx); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("Invalid project: %v", err)})
+		}
 		r := repositories.NewTaskRepository(collection)
+		existing, err := r.GetTask(task.ID, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		if err := prepareRecurrence(task, existing); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		if cfg.BlockCompletion && task.Status == "completed" {
+			blockers, err := r.OpenBlockers(task.ID, workspace.ID, ctx)
+			if err != nil {
+				return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+			}
+			if len(blockers) > 0 {
+				return c.Status(409).JSON(fiber.Map{"message": "Task is blocked by open tasks", "blocked_by": blockers})
+			}
+		}
 		if _, err := r.UpdateTask(task, ctx); err != nil {
 			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
 		}
+		publishTaskUpdated(pub, r, workspace.ID, ctx, task.ID)
+		notifyTaskChanged(existing, task, user, n, ctx)
+		if cfg.AutoCompleteParent && task.Status == "completed" {
+			if err := r.CompleteParents(task.ID, workspace.ID, ctx); err != nil {
+				return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+			}
+			publishTaskUpdated(pub, r, workspace.ID, ctx, existing.Ancestors...)
+		}
+		if existing.Status != "completed" && task.Status == "completed" && task.Recurrence != "" {
+			next, err := createNextOccurrence(r, task.ID, workspace.ID, user.Location(), ctx)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+			}
+			if next != nil {
+				next.CalcChecklistProgress()
+				publishTask(pub, events.TaskCreated, next)
+				return c.Status(200).JSON(fiber.Map{"message": "Task edited successfully", "next_task": next})
+			}
+		}
 		return c.Status(200).JSON(fiber.Map{"message": "Task edited successfully"})
 	}
 }
 
-func DeleteTask(collection *mongo.Collection) fiber.Handler {
+// DeleteTask переносит задачу в корзину; комментарии и вложения удаляются при очистке корзины
+func DeleteTask(collection, undoCollection *mongo.Collection, n notifier.Notifier, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		r := repositories.NewTaskRepository(collection)
+		task, err := r.GetTask(taskID, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		deleted, err := r.DeleteTask(workspace.ID, taskID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTasksDeleted(pub, workspace.ID, deleted)
+		notifyTaskDeleted(task, user, n, ctx)
+		response := fiber.Map{"message": "Task moved to trash"}
+		return c.Status(200).JSON(issueUndo(response, undoCollection, user, workspace.ID, models.UndoTaskDelete, taskUndo{TaskID: taskID}, ctx))
+	}
+}
+
+type moveTaskRequest struct {
+	ParentID string `json:"parent_id"`
+}
+
+func CreateSubtask(collection, projectCollection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		parentID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		task := new(models.Task)
+		if err := c.BodyParser(task); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(task); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		task.WorkspaceID = workspace.ID
+		task.UserID = user.ID
+		task.Tags = normalizeTags(task.Tags)
+		task.BlockedBy = nil
+		task.ExternalID = ""
+		for i := range task.Checklist {
+			task.Checklist[i].ID = primitive.NewObjectID()
+		}
+		if task.ProjectID != nil && task.ProjectID.IsZero() {
+			task.ProjectID = nil
+		}
+		if err := prepareRecurrence(task, nil); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		if err := r.PrepareSubtask(task, parentID, workspace.ID, ctx); err != nil {
+			if err == repositories.ErrMaxTaskDepth {
+				return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+			}
+			return c.Status(404).JSON(fiber.Map{"message": "Parent task not found"})
+		}
+		if err := checkTaskProject(task, workspace.ID, projectCollection, collection, ctx); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("Invalid project: %v", err)})
+		}
+		result, err := r.CreateTask(task, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		task.ID = result.InsertedID.(primitive.ObjectID)
+		task.CalcChecklistProgress()
+		publishTask(pub, events.TaskCreated, task)
+		return c.Status(201).JSON(fiber.Map{"message": "Subtask created successfully", "id": result.InsertedID})
+	}
+}
+
+func MoveTask(collection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		req := new(moveTaskRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		var parentID *primitive.ObjectID
+		if req.ParentID != "" {
+			id, err := primitive.ObjectIDFromHex(req.ParentID)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid parent ID"})
+			}
+			parentID = &id
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		if err := r.MoveTask(taskID, parentID, workspace.ID, ctx); err != nil {
+			if err == repositories.ErrTaskCycle || err == repositories.ErrMaxTaskDepth {
+				return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+			}
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTaskUpdated(pub, r, workspace.ID, ctx, taskID)
+		return c.Status(200).JSON(fiber.Map{"message": "Task moved successfully"})
+	}
+}
+
+func GetTaskTree(collection *mongo.Collection) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
 		defer cancel()
-		userID := (c.Locals("user").(*models.User)).ID
+		workspace := c.Locals("workspace").(*models.Workspace)
 
 		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
 		if err != nil {
 			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
 		}
 		r := repositories.NewTaskRepository(collection)
-		if _, err := r.DeleteTask(userID, taskID, ctx); err != nil {
+		tree, err := r.GetTaskTree(taskID, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"task": tree})
+	}
+}
+
+type dependencyRequest struct {
+	BlockerID string `json:"blocker_id" validate:"required"`
+}
+
+type scheduleRequest struct {
+	TaskIDs []string `json:"task_ids"`
+}
+
+func AddDependency(collection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		req := new(dependencyRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		blockerID, err := primitive.ObjectIDFromHex(req.BlockerID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid blocker ID"})
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		if err := r.AddDependency(taskID, blockerID, workspace.ID, ctx); err != nil {
+			if err == repositories.ErrTaskSelf || err == repositories.ErrDependency {
+				return c.Status(409).JSON(fiber.Map{"message": err.Error()})
+			}
+			return c.Status(404).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTaskUpdated(pub, r, workspace.ID, ctx, taskID)
+		return c.Status(200).JSON(fiber.Map{"message": "Dependency added successfully"})
+	}
+}
+
+func RemoveDependency(collection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		blockerID, err := primitive.ObjectIDFromHex(c.Params("blocker"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid blocker ID"})
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		result, err := r.RemoveDependency(taskID, blockerID, workspace.ID, ctx)
+		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
 		}
-		return c.Status(200).JSON(fiber.Map{"message": "Task deleted successfully"})
+		if result.MatchedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		publishTaskUpdated(pub, r, workspace.ID, ctx, taskID)
+		return c.Status(200).JSON(fiber.Map{"message": "Dependency removed successfully"})
+	}
+}
+
+func GetTaskSchedule(collection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		req := new(scheduleRequest)
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(req); err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+			}
+		}
+		taskIDs, err := parseObjectIDs(req.TaskIDs)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		schedule, err := r.Schedule(taskIDs, workspace.ID, ctx)
+		if err == utils.ErrCycle {
+			return c.Status(409).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"schedule": schedule})
+	}
+}
+
+type checklistItemRequest struct {
+	Text     string `json:"text" validate:"required,max=500"`
+	Position *int   `json:"position" validate:"omitempty,min=0"`
+}
+
+type moveChecklistItemRequest struct {
+	Position int `json:"position" validate:"min=0"`
+}
+
+func AddChecklistItem(collection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		req := new(checklistItemRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		task, err := r.AddChecklistItem(taskID, models.ChecklistItem{Text: req.Text}, req.Position, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTask(pub, events.TaskUpdated, task)
+		return c.Status(201).JSON(fiber.Map{"checklist": task.Checklist, "checklist_progress": task.ChecklistProgress})
+	}
+}
+
+func ToggleChecklistItem(collection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, itemID, err := checklistParams(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		r := repositories.NewTaskRepository(collection)
+		task, err := r.ToggleChecklistItem(taskID, itemID, workspace.ID, ctx)
+		if err == repositories.ErrConflict {
+			return c.Status(409).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTask(pub, events.TaskUpdated, task)
+		return c.Status(200).JSON(fiber.Map{"checklist": task.Checklist, "checklist_progress": task.ChecklistProgress})
+	}
+}
+
+func MoveChecklistItem(collection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, itemID, err := checklistParams(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		req := new(moveChecklistItemRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		r := repositories.NewTaskRepository(collection)
+		task, err := r.MoveChecklistItem(taskID, itemID, req.Position, workspace.ID, ctx)
+		if err == repositories.ErrConflict {
+			return c.Status(409).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTask(pub, events.TaskUpdated, task)
+		return c.Status(200).JSON(fiber.Map{"checklist": task.Checklist, "checklist_progress": task.ChecklistProgress})
+	}
+}
+
+func DeleteChecklistItem(collection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, itemID, err := checklistParams(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		r := repositories.NewTaskRepository(collection)
+		task, err := r.DeleteChecklistItem(taskID, itemID, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTask(pub, events.TaskUpdated, task)
+		return c.Status(200).JSON(fiber.Map{"checklist": task.Checklist, "checklist_progress": task.ChecklistProgress})
+	}
+}
+
+func checklistParams(c *fiber.Ctx) (primitive.ObjectID, primitive.ObjectID, error) {
+	taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return taskID, taskID, fmt.Errorf("Invalid task ID")
+	}
+	itemID, err := primitive.ObjectIDFromHex(c.Params("item"))
+	if err != nil {
+		return taskID, itemID, fmt.Errorf("Invalid checklist item ID")
+	}
+	return taskID, itemID, nil
+}
+
+func parseTaskFilter(c *fiber.Ctx) repositories.TaskFilter {
+	var filter repositories.TaskFilter
+	if tags := c.Query("tags"); tags != "" {
+		filter.Tags = normalizeTags(strings.Split(tags, ","))
 	}
+	filter.MatchAllTags = c.Query("tags_mode") == "all"
+	return filter
 }
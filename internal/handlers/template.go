@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"task_manager/internal/utils"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+func CreateTemplate(templateCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+		template := new(models.TaskTemplate)
+		if err := c.BodyParser(template); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(template); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		if _, err := checkTemplate(template); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		template.WorkspaceID = workspace.ID
+		template.UserID = user.ID
+		r := repositories.NewTemplateRepository(templateCollection)
+		result, err := r.CreateTemplate(template, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(201).JSON(fiber.Map{"message": "Template created successfully", "id": result.InsertedID})
+	}
+}
+
+func GetTemplates(templateCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+		r := repositories.NewTemplateRepository(templateCollection)
+		templates, err := r.GetTemplates(workspace.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		for i := range templates {
+			templates[i].Variables = templateVariables(&templates[i])
+		}
+		return c.Status(200).JSON(fiber.Map{"templates": templates})
+	}
+}
+
+func GetTemplate(templateCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		templateID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid template ID"})
+		}
+		r := repositories.NewTemplateRepository(templateCollection)
+		template, err := r.GetTemplate(workspace.ID, templateID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Template not found"})
+		}
+		template.Variables = templateVariables(template)
+		return c.Status(200).JSON(fiber.Map{"template": template})
+	}
+}
+
+func EditTemplate(templateCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		templateID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid template ID"})
+		}
+		template := new(models.TaskTemplate)
+		if err := c.BodyParser(template); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(template); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		if _, err := checkTemplate(template); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		template.ID = templateID
+		template.WorkspaceID = workspace.ID
+		r := repositories.NewTemplateRepository(templateCollection)
+		result, err := r.UpdateTemplate(template, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if result.MatchedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Template not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Template edited successfully"})
+	}
+}
+
+func DeleteTemplate(templateCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		templateID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid template ID"})
+		}
+		r := repositories.NewTemplateRepository(templateCollection)
+		result, err := r.DeleteTemplate(workspace.ID, templateID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if result.DeletedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Template not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Template deleted successfully"})
+	}
+}
+
+// InstantiateTemplate создаёт задачи по шаблону. Переменные {{name}} берутся из variables;
+// встроенные {{date}}, {{user}} и {{workspace}} можно переопределить. Сроки отсчитываются от base_date
+// (по умолчанию — сегодня в часовом поясе пользователя). С parent_id задачи верхнего уровня
+// становятся подзадачами указанной задачи.
+func InstantiateTemplate(templateCollection, taskCollection, projectCollection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		templateID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid template ID"})
+		}
+		var body struct {
+			Variables map[string]string   `json:"variables"`
+			BaseDate  *time.Time          `json:"base_date"`
+			ProjectID *primitive.ObjectID `json:"project_id"`
+			ParentID  *primitive.ObjectID `json:"parent_id"`
+		}
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&body); err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+			}
+		}
+		tr := repositories.NewTemplateRepository(templateCollection)
+		template, err := tr.GetTemplate(workspace.ID, templateID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Template not found"})
+		}
+		order, err := checkTemplate(template)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		base := body.BaseDate
+		if base == nil {
+			// Дата без времени хранится как полночь UTC, как и сроки на весь день
+			y, m, d := time.Now().In(user.Location()).Date()
+			today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+			base = &today
+		}
+		vars := map[string]string{
+			"date":      base.Format("2006-01-02"),
+			"user":      user.Username,
+			"workspace": workspace.Name,
+		}
+		for name, value := range body.Variables {
+			vars[name] = value
+		}
+		var missing []string
+		for _, name := range templateVariables(template) {
+			if _, ok := vars[name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return c.Status(400).JSON(fiber.Map{"message": "Missing template variables", "missing": missing})
+		}
+
+		// Общий прототип задач верхнего уровня: проект и место в дереве
+		root := &models.Task{ProjectID: body.ProjectID}
+		r := repositories.NewTaskRepository(taskCollection)
+		if body.ParentID != nil {
+			if err := r.PrepareSubtask(root, *body.ParentID, workspace.ID, ctx); err != nil {
+				if err == repositories.ErrMaxTaskDepth {
+					return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+				}
+				return c.Status(404).JSON(fiber.Map{"message": "Parent task not found"})
+			}
+		}
+		if err := checkTaskProject(root, workspace.ID, projectCollection, taskCollection, ctx); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("Invalid project: %v", err)})
+		}
+
+		keys := map[string]primitive.ObjectID{}
+		for _, tt := range template.Tasks {
+			if tt.Key != "" {
+				keys[tt.Key] = primitive.NewObjectID()
+			}
+		}
+		validate := validator.New()
+		tasks := make([]*models.Task, len(template.Tasks))
+		byKey := map[string]*models.Task{}
+		// Родители идут в order раньше детей, поэтому их Ancestors уже заполнены
+		for _, i := range order {
+			tt := template.Tasks[i]
+			task := &models.Task{
+				ID:          keys[tt.Key],
+				WorkspaceID: workspace.ID,
+				UserID:      user.ID,
+				ProjectID:   root.ProjectID,
+				ParentID:    root.ParentID,
+				Ancestors:   root.Ancestors,
+				Title:       strings.TrimSpace(utils.RenderTemplate(tt.Title, vars)),
+				Description: utils.RenderTemplate(tt.Description, vars),
+				Status:      "pending",
+				Priority:    tt.Priority,
+				Estimate:    tt.Estimate,
+			}
+			if task.Priority == "" {
+				task.Priority = "medium"
+			}
+			if tt.Parent != "" {
+				parent := byKey[tt.Parent]
+				task.ParentID = &parent.ID
+				task.Ancestors = append(append([]primitive.ObjectID{}, parent.Ancestors...), parent.ID)
+				if len(task.Ancestors) > cfg.MaxTaskDepth {
+					return c.Status(400).JSON(fiber.Map{"message": repositories.ErrMaxTaskDepth.Error()})
+				}
+			}
+			if tt.DueOffset != "" {
+				due, err := utils.ApplyDueOffset(*base, tt.DueOffset)
+				if err != nil {
+					return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+				}
+				task.DueDate = &due
+			}
+			tags := make([]string, len(tt.Tags))
+			for j, tag := range tt.Tags {
+				tags[j] = utils.RenderTemplate(tag, vars)
+			}
+			task.Tags = normalizeTags(tags)
+			for _, text := range tt.Checklist {
+				task.Checklist = append(task.Checklist, models.ChecklistItem{
+					ID:   primitive.NewObjectID(),
+					Text: strings.TrimSpace(utils.RenderTemplate(text, vars)),
+				})
+			}
+			for _, blocker := range tt.BlockedBy {
+				task.BlockedBy = append(task.BlockedBy, keys[blocker])
+			}
+			if err := validate.Struct(task); err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("Task %d: %v", i+1, err)})
+			}
+			if tt.Key != "" {
+				byKey[tt.Key] = task
+			}
+			tasks[i] = task
+		}
+
+		if err := r.CreateTasks(tasks, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		for _, task := range tasks {
+			task.CalcChecklistProgress()
+			publishTask(pub, events.TaskCreated, task)
+		}
+		return c.Status(201).JSON(fiber.Map{"message": "Template instantiated successfully", "tasks": tasks})
+	}
+}
+
+// checkTemplate проверяет ссылки между задачами шаблона и возвращает порядок создания:
+// родитель раньше подзадач. Циклы через parent и blocked_by запрещены.
+func checkTemplate(template *models.TaskTemplate) ([]int, error) {
+	index := map[string]int{}
+	for i := range template.Tasks {
+		tt := &template.Tasks[i]
+		tt.Key = strings.TrimSpace(tt.Key)
+		if tt.Key == "" {
+			continue
+		}
+		if _, ok := index[tt.Key]; ok {
+			return nil, fmt.Errorf("duplicate task key %q", tt.Key)
+		}
+		index[tt.Key] = i
+	}
+
+	nodes := make([]int, len(template.Tasks))
+	deps := map[int][]int{}
+	for i, tt := range template.Tasks {
+		nodes[i] = i
+		if tt.DueOffset != "" {
+			if err := utils.ValidateDueOffset(tt.DueOffset); err != nil {
+				return nil, err
+			}
+		}
+		refs := tt.BlockedBy
+		if tt.Parent != "" {
+			refs = append([]string{tt.Parent}, refs...)
+		}
+		for _, ref := range refs {
+			j, ok := index[ref]
+			if !ok {
+				return nil, fmt.Errorf("task %d references unknown key %q", i+1, ref)
+			}
+			if j == i {
+				return nil, fmt.Errorf("task %d references itself", i+1)
+			}
+			deps[i] = append(deps[i], j)
+		}
+	}
+	order, err := utils.TopologicalSort(nodes, deps)
+	if err != nil {
+		return nil, fmt.Errorf("template tasks form a cycle")
+	}
+
+	depth := make([]int, len(template.Tasks))
+	for _, i := range order {
+		if parent := template.Tasks[i].Parent; parent != "" {
+			depth[i] = depth[index[parent]] + 1
+			if depth[i] > cfg.MaxTaskDepth {
+				return nil, repositories.ErrMaxTaskDepth
+			}
+		}
+	}
+	return order, nil
+}
+
+// templateVariables возвращает переменные, которые используются в шаблоне
+func templateVariables(template *models.TaskTemplate) []string {
+	texts := []string{}
+	for _, tt := range template.Tasks {
+		texts = append(texts, tt.Title, tt.Description)
+		texts = append(texts, tt.Tags...)
+		texts = append(texts, tt.Checklist...)
+	}
+	names := utils.TemplateVariables(texts...)
+	if names == nil {
+		names = []string{}
+	}
+	return names
+}
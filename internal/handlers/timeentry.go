@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+// timeEntryMaxDuration ограничивает ручную запись: больше суток — скорее всего опечатка
+const timeEntryMaxDuration = 24 * time.Hour
+
+// timesheetMaxDays ограничивает период табеля
+const timesheetMaxDays = 366
+
+var timesheetColumns = []string{"date", "user", "project", "task", "task_id", "started_at", "ended_at", "hours", "note"}
+
+type timeEntryRequest struct {
+	StartedAt *time.Time `json:"started_at" validate:"required"`
+	EndedAt   *time.Time `json:"ended_at"`
+	// Minutes можно передать вместо ended_at
+	Minutes int    `json:"minutes" validate:"min=0"`
+	Note    string `json:"note" validate:"max=500"`
+}
+
+// StartTimer запускает таймер по задаче. Пока таймер идёт, второй запустить нельзя:
+// сначала нужно остановить текущий.
+func StartTimer(timeCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		entry := new(models.TimeEntry)
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(entry); err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+			}
+		}
+		validate := validator.New()
+		if err := validate.Struct(entry); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		tr := repositories.NewTaskRepository(taskCollection)
+		if _, err := tr.GetTask(taskID, workspace.ID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+
+		entry.ID = primitive.NilObjectID
+		entry.WorkspaceID = workspace.ID
+		entry.TaskID = taskID
+		entry.UserID = user.ID
+		r := repositories.NewTimeEntryRepository(timeCollection, taskCollection)
+		result, err := r.StartTimer(entry, ctx)
+		if err == repositories.ErrTimerRunning {
+			running, _ := r.GetRunningTimer(user.ID, ctx)
+			return c.Status(409).JSON(fiber.Map{"message": err.Error(), "timer": running})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		entry.ID = result.InsertedID.(primitive.ObjectID)
+		return c.Status(201).JSON(fiber.Map{"message": "Timer started", "timer": entry})
+	}
+}
+
+func StopTimer(timeCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		r := repositories.NewTimeEntryRepository(timeCollection, taskCollection)
+		entry, err := r.StopTimer(user.ID, ctx)
+		if err == repositories.ErrNoRunningTimer {
+			return c.Status(404).JSON(fiber.Map{"message": "No running timer"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Timer stopped", "entry": entry})
+	}
+}
+
+// GetRunningTimer возвращает запущенный таймер пользователя; он может идти и в другом пространстве
+func GetRunningTimer(timeCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		r := repositories.NewTimeEntryRepository(timeCollection, taskCollection)
+		entry, err := r.GetRunningTimer(user.ID, ctx)
+		if err == repositories.ErrNoRunningTimer {
+			return c.Status(200).JSON(fiber.Map{"timer": nil})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"timer": entry, "elapsed": int64(time.Since(entry.StartedAt) / time.Second)})
+	}
+}
+
+func CreateTimeEntry(timeCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		entry, err := parseTimeEntry(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		tr := repositories.NewTaskRepository(taskCollection)
+		if _, err := tr.GetTask(taskID, workspace.ID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+
+		entry.WorkspaceID = workspace.ID
+		entry.TaskID = taskID
+		entry.UserID = user.ID
+		r := repositories.NewTimeEntryRepository(timeCollection, taskCollection)
+		result, err := r.CreateEntry(entry, ctx)
+		if err == repositories.ErrTimeOverlap {
+			return c.Status(409).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		entry.ID = result.InsertedID.(primitive.ObjectID)
+		return c.Status(201).JSON(fiber.Map{"message": "Time entry created successfully", "entry": entry})
+	}
+}
+
+func EditTimeEntry(timeCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		entryID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid time entry ID"})
+		}
+		entry, err := parseTimeEntry(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		entry.ID = entryID
+		entry.WorkspaceID = workspace.ID
+		entry.UserID = user.ID
+		r := repositories.NewTimeEntryRepository(timeCollection, taskCollection)
+		result, err := r.UpdateEntry(entry, ctx)
+		if err == repositories.ErrTimeOverlap {
+			return c.Status(409).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if result.MatchedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Time entry not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Time entry edited successfully"})
+	}
+}
+
+// DeleteTimeEntry удаляет свою запись; так же можно отменить запущенный таймер, не сохраняя время
+func DeleteTimeEntry(timeCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		entryID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid time entry ID"})
+		}
+		r := repositories.NewTimeEntryRepository(timeCollection, taskCollection)
+		result, err := r.DeleteEntry(workspace.ID, user.ID, entryID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if result.DeletedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Time entry not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Time entry deleted successfully"})
+	}
+}
+
+// GetTaskTime возвращает записи времени по задаче и их сумму; идущий таймер учитывается до текущего момента
+func GetTaskTime(timeCollection, taskCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		r := repositories.NewTimeEntryRepository(timeCollection, taskCollection)
+		entries, err := r.GetTaskEntries(workspace.ID, taskID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		var total int64
+		for _, entry := range entries {
+			if entry.Running {
+				total += int64(time.Since(entry.StartedAt) / time.Second)
+			} else {
+				total += entry.Duration
+			}
+		}
+		return c.Status(200).JSON(fiber.Map{"entries": entries, "total": total})
+	}
+}
+
+// GetTimesheet отдаёт табель за период from–to (даты включительно, в часовом поясе пользователя)
+// с итогами по дням, задачам, проектам и пользователям. С format=csv отдаёт записи в CSV для биллинга.
+// По умолчанию период — текущий месяц.
+func GetTimesheet(timeCollection, taskCollection, projectCollection, userCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		loc := user.Location()
+		y, m, _ := time.Now().In(loc).Date()
+		filter := repositories.TimesheetFilter{
+			From: time.Date(y, m, 1, 0, 0, 0, 0, loc),
+			To:   time.Date(y, m+1, 1, 0, 0, 0, 0, loc),
+		}
+		if from := c.Query("from"); from != "" {
+			date, err := time.ParseInLocation("2006-01-02", from, loc)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid from date"})
+			}
+			filter.From = date
+		}
+		if to := c.Query("to"); to != "" {
+			date, err := time.ParseInLocation("2006-01-02", to, loc)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid to date"})
+			}
+			filter.To = date.AddDate(0, 0, 1)
+		}
+		if !filter.To.After(filter.From) || filter.To.Sub(filter.From) > timesheetMaxDays*24*time.Hour {
+			return c.Status(400).JSON(fiber.Map{"message": fmt.Sprintf("Invalid period: up to %d days", timesheetMaxDays)})
+		}
+		if userID := c.Query("user_id"); userID != "" {
+			id, err := primitive.ObjectIDFromHex(userID)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid user ID"})
+			}
+			filter.UserID = &id
+		}
+		if projectID := c.Query("project_id"); projectID != "" {
+			id, err := primitive.ObjectIDFromHex(projectID)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid project ID"})
+			}
+			filter.ProjectID = &id
+		}
+		format := c.Query("format", "json")
+		if format != "json" && format != "csv" {
+			return c.Status(400).JSON(fiber.Map{"message": "Unsupported format"})
+		}
+
+		r := repositories.NewTimeEntryRepository(timeCollection, taskCollection)
+		entries, err := r.GetTimesheet(workspace.ID, filter, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		pr := repositories.NewProjectRepository(projectCollection, taskCollection)
+		projects, err := pr.GetProjects(workspace.ID, true, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		projectNames := map[primitive.ObjectID]string{}
+		for _, project := range projects {
+			projectNames[project.ID] = project.Name
+		}
+		ur := repositories.NewUserRepository(userCollection)
+		usernames := map[primitive.ObjectID]string{}
+		for i := range entries {
+			entry := &entries[i]
+			if _, ok := usernames[entry.UserID]; !ok {
+				usernames[entry.UserID] = ""
+				if u, err := ur.GetUser(entry.UserID, ctx); err == nil {
+					usernames[entry.UserID] = u.Username
+				}
+			}
+			entry.User = usernames[entry.UserID]
+			if entry.ProjectID != nil {
+				entry.Project = projectNames[*entry.ProjectID]
+			}
+			entry.Date = entry.StartedAt.In(loc).Format("2006-01-02")
+		}
+
+		if format == "csv" {
+			c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+			c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="timesheet-%s-%s.csv"`,
+				filter.From.Format("2006-01-02"), filter.To.AddDate(0, 0, -1).Format("2006-01-02")))
+			return writeTimesheetCSV(c, entries, loc)
+		}
+		return c.Status(200).JSON(fiber.Map{
+			"from":    filter.From.Format("2006-01-02"),
+			"to":      filter.To.AddDate(0, 0, -1).Format("2006-01-02"),
+			"entries": entries,
+			"totals":  timesheetTotals(entries),
+		})
+	}
+}
+
+// parseTimeEntry читает интервал ручной записи: started_at и ended_at либо started_at и minutes
+func parseTimeEntry(c *fiber.Ctx) (*models.TimeEntry, error) {
+	req := new(timeEntryRequest)
+	if err := c.BodyParser(req); err != nil {
+		return nil, fmt.Errorf("Invalid request body")
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if (req.EndedAt != nil) == (req.Minutes > 0) {
+		return nil, fmt.Errorf("Either ended_at or minutes is required")
+	}
+	end := req.StartedAt.Add(time.Duration(req.Minutes) * time.Minute)
+	if req.EndedAt != nil {
+		end = *req.EndedAt
+	}
+	if !end.After(*req.StartedAt) {
+		return nil, fmt.Errorf("ended_at must be after started_at")
+	}
+	if end.Sub(*req.StartedAt) > timeEntryMaxDuration {
+		return nil, fmt.Errorf("Time entry cannot be longer than %v", timeEntryMaxDuration)
+	}
+	if end.After(time.Now().Add(time.Minute)) {
+		return nil, fmt.Errorf("Time entry cannot end in the future")
+	}
+	return &models.TimeEntry{StartedAt: *req.StartedAt, EndedAt: &end, Note: req.Note}, nil
+}
+
+// timesheetTotals суммирует время записей по дням, задачам, проектам и пользователям
+func timesheetTotals(entries []models.TimesheetEntry) fiber.Map {
+	byDay := map[string]*models.TimeTotal{}
+	byTask := map[string]*models.TimeTotal{}
+	byProject := map[string]*models.TimeTotal{}
+	byUser := map[string]*models.TimeTotal{}
+	add := func(totals map[string]*models.TimeTotal, key, name string, duration int64) {
+		if totals[key] == nil {
+			totals[key] = &models.TimeTotal{Key: key, Name: name}
+		}
+		totals[key].Duration += duration
+	}
+	var total int64
+	for _, entry := range entries {
+		total += entry.Duration
+		add(byDay, entry.Date, "", entry.Duration)
+		add(byTask, entry.TaskID.Hex(), entry.TaskTitle, entry.Duration)
+		add(byUser, entry.UserID.Hex(), entry.User, entry.Duration)
+		// Время по задачам без проекта собирается под пустым ключом
+		projectKey := ""
+		if entry.ProjectID != nil {
+			projectKey = entry.ProjectID.Hex()
+		}
+		add(byProject, projectKey, entry.Project, entry.Duration)
+	}
+	return fiber.Map{
+		"total":      total,
+		"by_day":     sortedTotals(byDay, true),
+		"by_task":    sortedTotals(byTask, false),
+		"by_project": sortedTotals(byProject, false),
+		"by_user":    sortedTotals(byUser, false),
+	}
+}
+
+// sortedTotals упорядочивает итоги по ключу (для дней) или по убыванию времени
+func sortedTotals(totals map[string]*models.TimeTotal, byKey bool) []models.TimeTotal {
+	result := make([]models.TimeTotal, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if byKey || result[i].Duration == result[j].Duration {
+			return result[i].Key < result[j].Key
+		}
+		return result[i].Duration > result[j].Duration
+	})
+	return result
+}
+
+func writeTimesheetCSV(c *fiber.Ctx, entries []models.TimesheetEntry, loc *time.Location) error {
+	cw := csv.NewWriter(c)
+	if err := cw.Write(timesheetColumns); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		ended := ""
+		if entry.EndedAt != nil {
+			ended = entry.EndedAt.In(loc).Format(time.RFC3339)
+		}
+		row := []string{
+			entry.Date,
+			entry.User,
+			entry.Project,
+			entry.TaskTitle,
+			entry.TaskID.Hex(),
+			entry.StartedAt.In(loc).Format(time.RFC3339),
+			ended,
+			strconv.FormatFloat(float64(entry.Duration)/3600, 'f', 2, 64),
+			entry.Note,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
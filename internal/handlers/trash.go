@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"task_manager/internal/storage"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+func GetTrash(collection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		r := repositories.NewTaskRepository(collection)
+		tasks, err := r.GetTrash(workspace.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"tasks": tasks, "retention_days": int(cfg.TrashRetention.Hours() / 24)})
+	}
+}
+
+func RestoreTask(collection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		r := repositories.NewTaskRepository(collection)
+		restored, err := r.RestoreTask(workspace.ID, taskID, ctx)
+		if err == repositories.ErrNotInTrash {
+			return c.Status(404).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err == repositories.ErrParentDeleted {
+			return c.Status(409).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		for _, id := range restored {
+			if task, err := r.GetTask(id, workspace.ID, ctx); err == nil {
+				publishTask(pub, events.TaskCreated, task)
+			}
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Task restored successfully", "restored": restored})
+	}
+}
+
+// PurgeTask окончательно удаляет задачу из корзины вместе с комментариями и вложениями
+func PurgeTask(collection, commentCollection, attachmentCollection *mongo.Collection, store storage.BlobStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		r := repositories.NewTaskRepository(collection)
+		purged, err := r.PurgeTask(workspace.ID, taskID, ctx)
+		if err == repositories.ErrNotInTrash {
+			return c.Status(404).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if err := purgeTaskData(purged, commentCollection, attachmentCollection, store, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Task deleted permanently"})
+	}
+}
+
+// EmptyExpiredTrash окончательно удаляет задачи, пролежавшие в корзине дольше retention
+func EmptyExpiredTrash(collection, commentCollection, attachmentCollection *mongo.Collection, store storage.BlobStore, retention time.Duration, ctx context.Context) error {
+	r := repositories.NewTaskRepository(collection)
+	purged, err := r.PurgeDeletedBefore(time.Now().Add(-retention), ctx)
+	if err != nil {
+		return err
+	}
+	return purgeTaskData(purged, commentCollection, attachmentCollection, store, ctx)
+}
+
+func purgeTaskData(taskIDs []primitive.ObjectID, commentCollection, attachmentCollection *mongo.Collection, store storage.BlobStore, ctx context.Context) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	cr := repositories.NewCommentRepository(commentCollection)
+	if _, err := cr.DeleteTaskComments(taskIDs, ctx); err != nil {
+		return err
+	}
+	return cleanupAttachments(taskIDs, attachmentCollection, store, ctx)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"task_manager/internal/utils"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+type undoRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// taskUndo — данные для отмены удаления задачи в корзину
+type taskUndo struct {
+	TaskID primitive.ObjectID `bson:"task_id"`
+}
+
+// Undo выполняет обратную операцию по токену, выданному в ответе на изменение
+func Undo(undoCollection, taskCollection, labelCollection, workspaceCollection, inviteCollection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		req := new(undoRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		ur := repositories.NewUndoRepository(undoCollection)
+		action, err := ur.ClaimUndo(utils.HashToken(strings.TrimSpace(req.Token)), user.ID, ctx)
+		if err == repositories.ErrUndoExpired {
+			return c.Status(410).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+
+		// Права могли измениться с момента исходной операции
+		wr := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		workspace, err := wr.GetWorkspace(action.WorkspaceID, ctx)
+		if err != nil || !models.RoleAtLeast(workspace.Role(user.ID), models.RoleEditor) {
+			releaseUndo(ur, action, ctx)
+			return c.Status(403).JSON(fiber.Map{"message": "Forbidden"})
+		}
+
+		err = applyUndo(action, taskCollection, labelCollection, pub, ctx)
+		if err == nil {
+			return c.Status(200).JSON(fiber.Map{"message": "Operation undone", "operation": action.Operation})
+		}
+		releaseUndo(ur, action, ctx)
+		switch err {
+		case repositories.ErrNotInTrash:
+			return c.Status(410).JSON(fiber.Map{"message": err.Error()})
+		case repositories.ErrParentDeleted, repositories.ErrLabelExists:
+			return c.Status(409).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+	}
+}
+
+func applyUndo(action *models.UndoAction, taskCollection, labelCollection *mongo.Collection, pub events.Publisher, ctx context.Context) error {
+	switch action.Operation {
+	case models.UndoTaskDelete:
+		var payload taskUndo
+		if err := bson.Unmarshal(action.Payload, &payload); err != nil {
+			return err
+		}
+		r := repositories.NewTaskRepository(taskCollection)
+		restored, err := r.RestoreTask(action.WorkspaceID, payload.TaskID, ctx)
+		if err != nil {
+			return err
+		}
+		for _, id := range restored {
+			if task, err := r.GetTask(id, action.WorkspaceID, ctx); err == nil {
+				publishTask(pub, events.TaskCreated, task)
+			}
+		}
+		return nil
+	case models.UndoTaskBulk:
+		var payload models.BulkUndo
+		if err := bson.Unmarshal(action.Payload, &payload); err != nil {
+			return err
+		}
+		r := repositories.NewTaskRepository(taskCollection)
+		changed, err := r.UndoBulk(action.WorkspaceID, &payload, ctx)
+		if err != nil {
+			return err
+		}
+		if payload.Type == models.BulkDelete {
+			for _, id := range changed {
+				if task, err := r.GetTask(id, action.WorkspaceID, ctx); err == nil {
+					publishTask(pub, events.TaskCreated, task)
+				}
+			}
+			return nil
+		}
+		publishTaskUpdated(pub, r, action.WorkspaceID, ctx, changed...)
+		return nil
+	case models.UndoLabelMerge, models.UndoLabelDelete:
+		var payload models.LabelUndo
+		if err := bson.Unmarshal(action.Payload, &payload); err != nil {
+			return err
+		}
+		r := repositories.NewLabelRepository(labelCollection, taskCollection)
+		changed, err := r.UndoLabels(action.WorkspaceID, &payload, ctx)
+		if err != nil {
+			return err
+		}
+		publishTaskUpdated(pub, repositories.NewTaskRepository(taskCollection), action.WorkspaceID, ctx, changed...)
+		return nil
+	}
+	return fmt.Errorf("unknown undo operation %q", action.Operation)
+}
+
+func releaseUndo(r *repositories.UndoRepository, action *models.UndoAction, ctx context.Context) {
+	if err := r.ReleaseUndo(action.ID, ctx); err != nil {
+		log.Error().Err(err).Str("undo_id", action.ID.Hex()).Msg("failed to release undo token")
+	}
+}
+
+// issueUndo сохраняет обратную операцию и добавляет в ответ токен отмены.
+// Если сохранить не удалось, исходная операция всё равно считается выполненной — просто без отмены.
+func issueUndo(response fiber.Map, undoCollection *mongo.Collection, user *models.User, workspaceID primitive.ObjectID, operation string, payload interface{}, ctx context.Context) fiber.Map {
+	raw, err := bson.Marshal(payload)
+	if err != nil {
+		log.Error().Err(err).Str("operation", operation).Msg("failed to encode undo payload")
+		return response
+	}
+	token, err := utils.NewToken()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to generate undo token")
+		return response
+	}
+	action := &models.UndoAction{
+		WorkspaceID: workspaceID,
+		UserID:      user.ID,
+		Operation:   operation,
+		TokenHash:   utils.HashToken(token),
+		Payload:     raw,
+		ExpiresAt:   time.Now().Add(cfg.UndoWindow),
+	}
+	if _, err := repositories.NewUndoRepository(undoCollection).CreateUndo(action, ctx); err != nil {
+		log.Error().Err(err).Str("operation", operation).Msg("failed to store undo action")
+		return response
+	}
+	response["undo_token"] = token
+	response["undo_expires_at"] = action.ExpiresAt
+	return response
+}
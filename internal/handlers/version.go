@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+func GetTaskVersions(versionCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		page, limit := parsePagination(c)
+		r := repositories.NewVersionRepository(versionCollection)
+		versions, total, err := r.GetVersions(taskID, workspace.ID, page, limit, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if total == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"versions": versions, "page": page, "limit": limit, "total": total})
+	}
+}
+
+func GetTaskVersion(versionCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		number, err := strconv.Atoi(c.Params("version"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid version"})
+		}
+		r := repositories.NewVersionRepository(versionCollection)
+		version, err := r.GetVersion(taskID, workspace.ID, number, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Version not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"version": version})
+	}
+}
+
+// DiffTaskVersions сравнивает версии from и to задачи по полям
+func DiffTaskVersions(versionCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		from, to := c.QueryInt("from"), c.QueryInt("to")
+		if from < 1 || to < 1 {
+			return c.Status(400).JSON(fiber.Map{"message": "Query parameters from and to are required"})
+		}
+		r := repositories.NewVersionRepository(versionCollection)
+		old, err := r.GetVersion(taskID, workspace.ID, from, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": fmt.Sprintf("Version %d not found", from)})
+		}
+		next, err := r.GetVersion(taskID, workspace.ID, to, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": fmt.Sprintf("Version %d not found", to)})
+		}
+		changes, err := repositories.DiffTasks(&old.Task, &next.Task)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"from": from, "to": to, "changes": changes})
+	}
+}
+
+// RestoreTaskVersion возвращает задачу к прошлой версии. Проект, которого больше нет
+// или который архивирован, и участники, покинувшие пространство, из снимка не восстанавливаются.
+func RestoreTaskVersion(collection, versionCollection, projectCollection *mongo.Collection, pub events.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(actorContext(c), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid task ID"})
+		}
+		number, err := strconv.Atoi(c.Params("version"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid version"})
+		}
+		r := repositories.NewTaskRepository(collection)
+		if _, err := r.GetTask(taskID, workspace.ID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Task not found"})
+		}
+		vr := repositories.NewVersionRepository(versionCollection)
+		version, err := vr.GetVersion(taskID, workspace.ID, number, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Version not found"})
+		}
+
+		snapshot := &version.Task
+		if err := checkTaskProject(snapshot, workspace.ID, projectCollection, collection, ctx); err != nil {
+			snapshot.ProjectID = nil
+		}
+		if snapshot.AssigneeID != nil && workspace.Role(*snapshot.AssigneeID) == "" {
+			snapshot.AssigneeID = nil
+		}
+		watchers := snapshot.Watchers[:0]
+		for _, userID := range snapshot.Watchers {
+			if workspace.Role(userID) != "" {
+				watchers = append(watchers, userID)
+			}
+		}
+		snapshot.Watchers = watchers
+
+		if _, err := r.RestoreVersion(snapshot, workspace.ID, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		task, err := r.GetTask(taskID, workspace.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		publishTask(pub, events.TaskUpdated, task)
+		return c.Status(200).JSON(fiber.Map{"message": "Task restored to version " + c.Params("version"), "task": task})
+	}
+}
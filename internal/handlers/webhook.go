@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"fmt"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"task_manager/internal/utils"
+	"task_manager/internal/webhooks"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+func (req *webhookRequest) apply(webhook *models.Webhook) error {
+	webhook.URL = req.URL
+	webhook.Events = req.Events
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	validate := validator.New()
+	return validate.Struct(webhook)
+}
+
+func CreateWebhook(webhookCollection, deliveryCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		req := new(webhookRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		webhook := &models.Webhook{WorkspaceID: workspace.ID, UserID: user.ID, Active: true}
+		if err := req.apply(webhook); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		secret, err := utils.NewToken()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		webhook.Secret = secret
+
+		r := repositories.NewWebhookRepository(webhookCollection, deliveryCollection)
+		if _, err := r.CreateWebhook(webhook, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		// Секрет показывается только при создании
+		return c.Status(201).JSON(fiber.Map{"message": "Webhook created successfully", "webhook": webhook, "secret": secret})
+	}
+}
+
+func GetWebhooks(webhookCollection, deliveryCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		r := repositories.NewWebhookRepository(webhookCollection, deliveryCollection)
+		webhooks, err := r.GetWebhooks(workspace.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"webhooks": webhooks})
+	}
+}
+
+func EditWebhook(webhookCollection, deliveryCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		webhookID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid webhook ID"})
+		}
+		req := new(webhookRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		r := repositories.NewWebhookRepository(webhookCollection, deliveryCollection)
+		webhook, err := r.GetWorkspaceWebhook(workspace.ID, webhookID, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Webhook not found"})
+		}
+		if err := req.apply(webhook); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		webhook, err = r.UpdateWebhook(webhook, ctx)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Webhook not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Webhook edited successfully", "webhook": webhook})
+	}
+}
+
+func DeleteWebhook(webhookCollection, deliveryCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		webhookID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid webhook ID"})
+		}
+		r := repositories.NewWebhookRepository(webhookCollection, deliveryCollection)
+		result, err := r.DeleteWebhook(workspace.ID, webhookID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if result.DeletedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Webhook not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Webhook deleted successfully"})
+	}
+}
+
+func GetWebhookDeliveries(webhookCollection, deliveryCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		webhookID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid webhook ID"})
+		}
+		r := repositories.NewWebhookRepository(webhookCollection, deliveryCollection)
+		if _, err := r.GetWorkspaceWebhook(workspace.ID, webhookID, ctx); err != nil {
+			return c.Status(404).JSON(fiber.Map{"message": "Webhook not found"})
+		}
+		page, limit := parsePagination(c)
+		deliveries, total, err := r.GetDeliveries(webhookID, page, limit, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"deliveries": deliveries, "page": page, "limit": limit, "total": total})
+	}
+}
+
+func RedeliverWebhook(webhookCollection, deliveryCollection *mongo.Collection, dispatcher *webhooks.Dispatcher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		deliveryID, err := primitive.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid delivery ID"})
+		}
+		r := repositories.NewWebhookRepository(webhookCollection, deliveryCollection)
+		original, err := r.GetDelivery(deliveryID, ctx)
+		if err != nil || original.WorkspaceID != workspace.ID {
+			return c.Status(404).JSON(fiber.Map{"message": "Delivery not found"})
+		}
+		delivery, err := dispatcher.Redeliver(original, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(202).JSON(fiber.Map{"message": "Redelivery scheduled", "delivery": delivery})
+	}
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"task_manager/internal/models"
+	"task_manager/internal/notifier"
+	"task_manager/internal/repositories"
+	"task_manager/internal/utils"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/context"
+)
+
+type workspaceRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type inviteRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=editor viewer"`
+}
+
+type acceptInviteRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type memberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=editor viewer"`
+}
+
+func CreateWorkspace(workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		req := new(workspaceRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		workspace := &models.Workspace{Name: req.Name, OwnerID: user.ID}
+		r := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		if _, err := r.CreateWorkspace(workspace, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(201).JSON(fiber.Map{"message": "Workspace created successfully", "workspace": workspace})
+	}
+}
+
+func GetWorkspaces(workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		r := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		// Личное пространство создаётся при первом обращении
+		if _, err := r.PersonalWorkspace(user.ID, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		workspaces, err := r.GetWorkspaces(user.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"workspaces": workspaces})
+	}
+}
+
+func GetWorkspace(c *fiber.Ctx) error {
+	workspace := c.Locals("workspace").(*models.Workspace)
+	return c.Status(200).JSON(fiber.Map{"workspace": workspace, "role": c.Locals("role")})
+}
+
+func RenameWorkspace(workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		req := new(workspaceRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+		r := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		if _, err := r.RenameWorkspace(workspace.ID, req.Name, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Workspace renamed successfully"})
+	}
+}
+
+// InviteMember создаёт приглашение и отправляет токен на email приглашённого.
+// Токен возвращается только в ответе на этот запрос, в базе хранится его хеш.
+func InviteMember(workspaceCollection, inviteCollection *mongo.Collection, email notifier.EmailSender) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		if workspace.Personal {
+			return c.Status(400).JSON(fiber.Map{"message": "Personal workspace cannot be shared"})
+		}
+		req := new(inviteRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		token, err := utils.NewToken()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		invite := &models.Invite{
+			WorkspaceID: workspace.ID,
+			Email:       req.Email,
+			Role:        req.Role,
+			TokenHash:   utils.HashToken(token),
+			InvitedBy:   user.ID,
+			ExpiresAt:   time.Now().Add(cfg.InviteTTL),
+		}
+		r := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		if _, err := r.CreateInvite(invite, ctx); err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+
+		subject := fmt.Sprintf("%s invited you to %q", user.Username, workspace.Name)
+		body := fmt.Sprintf("You have been invited to the workspace %q as %s.\nInvite token: %s\nThe invite expires at %s.",
+			workspace.Name, invite.Role, token, invite.ExpiresAt.Format(time.RFC1123))
+		if err := email.Send(ctx, invite.Email, subject, body); err != nil {
+			log.Error().Err(err).Str("invite_id", invite.ID.Hex()).Msg("failed to send invite email")
+		}
+		return c.Status(201).JSON(fiber.Map{"message": "Invite created successfully", "invite": invite, "token": token})
+	}
+}
+
+func GetInvites(workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		r := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		invites, err := r.GetInvites(workspace.ID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"invites": invites})
+	}
+}
+
+func RevokeInvite(workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		inviteID, err := primitive.ObjectIDFromHex(c.Params("invite"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid invite ID"})
+		}
+		r := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		result, err := r.DeleteInvite(workspace.ID, inviteID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if result.DeletedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Invite not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Invite revoked successfully"})
+	}
+}
+
+func AcceptInvite(workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+
+		req := new(acceptInviteRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		r := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		workspace, err := r.AcceptInvite(utils.HashToken(strings.TrimSpace(req.Token)), user, ctx)
+		if err == repositories.ErrInviteInvalid {
+			return c.Status(404).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err == repositories.ErrAlreadyMember {
+			return c.Status(409).JSON(fiber.Map{"message": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Invite accepted successfully", "workspace": workspace})
+	}
+}
+
+func SetMemberRole(workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		memberID, err := primitive.ObjectIDFromHex(c.Params("user"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid user ID"})
+		}
+		req := new(memberRoleRequest)
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+		validate := validator.New()
+		if err := validate.Struct(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		r := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		result, err := r.SetMemberRole(workspace.ID, memberID, req.Role, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if result.MatchedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Member not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Member role updated successfully"})
+	}
+}
+
+// RemoveMember исключает участника; участник может и сам покинуть пространство
+func RemoveMember(workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		workspace := c.Locals("workspace").(*models.Workspace)
+
+		memberID, err := primitive.ObjectIDFromHex(c.Params("user"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"message": "Invalid user ID"})
+		}
+		if memberID != user.ID && workspace.Role(user.ID) != models.RoleOwner {
+			return c.Status(403).JSON(fiber.Map{"message": "Forbidden"})
+		}
+		r := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+		result, err := r.RemoveMember(workspace.ID, memberID, ctx)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": fmt.Sprintf("Error: %v", err)})
+		}
+		if result.MatchedCount == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Member not found"})
+		}
+		return c.Status(200).JSON(fiber.Map{"message": "Member removed successfully"})
+	}
+}
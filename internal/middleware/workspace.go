@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WorkspaceMiddleware определяет рабочее пространство запроса и роль пользователя в нём.
+// Пространство берётся из параметра маршрута :workspace, заголовка X-Workspace-ID
+// или параметра запроса workspace_id; без них используется личное пространство пользователя.
+// Должен выполняться после AuthMiddleware.
+func WorkspaceMiddleware(workspaceCollection, inviteCollection *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ContextTimeout)
+		defer cancel()
+		user := c.Locals("user").(*models.User)
+		r := repositories.NewWorkspaceRepository(workspaceCollection, inviteCollection)
+
+		id := c.Params("workspace")
+		if id == "" {
+			id = c.Get("X-Workspace-ID", c.Query("workspace_id"))
+		}
+		var workspace *models.Workspace
+		if id == "" {
+			personal, err := r.PersonalWorkspace(user.ID, ctx)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"message": "Failed to load workspace"})
+			}
+			workspace = personal
+		} else {
+			workspaceID, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"message": "Invalid workspace ID"})
+			}
+			workspace, err = r.GetWorkspace(workspaceID, ctx)
+			if err != nil {
+				return c.Status(404).JSON(fiber.Map{"message": "Workspace not found"})
+			}
+		}
+
+		role := workspace.Role(user.ID)
+		if role == "" {
+			// Чужие пространства неотличимы от несуществующих
+			return c.Status(404).JSON(fiber.Map{"message": "Workspace not found"})
+		}
+		c.Locals("workspace", workspace)
+		c.Locals("role", role)
+		return c.Next()
+	}
+}
+
+// RequireRole пропускает запрос, только если роль пользователя в пространстве не ниже role
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		current, _ := c.Locals("role").(string)
+		if !models.RoleAtLeast(current, role) {
+			return c.Status(403).JSON(fiber.Map{"message": "Forbidden"})
+		}
+		return c.Next()
+	}
+}
+
+// RequireRoleForWrites требует роль не ниже role для изменяющих запросов; чтение доступно всем участникам
+func RequireRoleForWrites(role string) fiber.Handler {
+	check := RequireRole(role)
+	return func(c *fiber.Ctx) error {
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			return c.Next()
+		}
+		return check(c)
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ActivityCreated  = "created"
+	ActivityUpdated  = "updated"
+	ActivityDeleted  = "deleted"
+	ActivityRestored = "restored"
+	ActivityPurged   = "purged"
+)
+
+// Activity — запись истории изменений задачи.
+// ActorID пуст, если изменение сделано фоновой задачей.
+type Activity struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	WorkspaceID primitive.ObjectID  `json:"workspace_id" bson:"workspace_id"`
+	TaskID      primitive.ObjectID  `json:"task_id" bson:"task_id"`
+	TaskTitle   string              `json:"task_title" bson:"task_title"`
+	ActorID     *primitive.ObjectID `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+	Action      string              `json:"action" bson:"action"`
+	Changes     []FieldChange       `json:"changes" bson:"changes"`
+	CreatedAt   time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// FieldChange — изменение одного поля задачи
+type FieldChange struct {
+	Field string      `json:"field" bson:"field"`
+	Old   interface{} `json:"old,omitempty" bson:"old,omitempty"`
+	New   interface{} `json:"new,omitempty" bson:"new,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Attachment struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TaskID      primitive.ObjectID `json:"task_id" bson:"task_id"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Filename    string             `json:"filename" bson:"filename"`
+	ContentType string             `json:"content_type" bson:"content_type"`
+	Size        int64              `json:"size" bson:"size"`
+	Key         string             `json:"-" bson:"key"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
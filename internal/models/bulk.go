@@ -0,0 +1,47 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	BulkSetStatus   = "set_status"
+	BulkSetPriority = "set_priority"
+	BulkAddTag      = "add_tag"
+	BulkDelete      = "delete"
+	BulkMoveProject = "move_project"
+)
+
+// Результаты обработки отдельной задачи в массовой операции
+const (
+	BulkUpdated   = "updated"
+	BulkDeleted   = "deleted"
+	BulkUnchanged = "unchanged"
+	BulkNotFound  = "not_found"
+	BulkBlocked   = "blocked"
+)
+
+// BulkOperation — изменение, применяемое ко всем выбранным задачам.
+// Заполнено только поле, соответствующее Type.
+type BulkOperation struct {
+	Type      string
+	Status    string
+	Priority  string
+	Tag       string
+	ProjectID *primitive.ObjectID
+}
+
+type BulkResult struct {
+	ID     primitive.ObjectID `json:"id"`
+	Result string             `json:"result"`
+}
+
+// BulkUndo — прежние значения задач, изменённых массовой операцией
+type BulkUndo struct {
+	Type  string         `bson:"type"`
+	Tag   string         `bson:"tag,omitempty"`
+	Items []BulkUndoItem `bson:"items"`
+}
+
+type BulkUndoItem struct {
+	TaskID primitive.ObjectID `bson:"task_id"`
+	Old    interface{}        `bson:"old,omitempty"`
+}
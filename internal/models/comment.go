@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Comment struct {
+	ID        primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	TaskID    primitive.ObjectID   `json:"task_id" bson:"task_id"`
+	UserID    primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	Body      string               `json:"body" bson:"body" validate:"required,max=10000"`
+	HTML      string               `json:"html" bson:"-"`
+	Mentions  []primitive.ObjectID `json:"mentions" bson:"mentions"`
+	History   []CommentRevision    `json:"history" bson:"history"`
+	CreatedAt time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// CommentRevision — предыдущая версия текста комментария
+type CommentRevision struct {
+	Body     string    `json:"body" bson:"body"`
+	EditedAt time.Time `json:"edited_at" bson:"edited_at"`
+}
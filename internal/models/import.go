@@ -0,0 +1,29 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Результаты импорта отдельной строки
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+)
+
+// ImportItem — задача, прочитанная из строки файла импорта.
+// Fields — bson-имена полей, которые были в строке: при обновлении меняются только они.
+type ImportItem struct {
+	Task   Task
+	Fields []string
+}
+
+type ImportResult struct {
+	Row        int                 `json:"row"`
+	ExternalID string              `json:"external_id,omitempty"`
+	TaskID     *primitive.ObjectID `json:"task_id,omitempty"`
+	Result     string              `json:"result"`
+	Error      string              `json:"error,omitempty"`
+	// Project — имя проекта, если он был указан по имени
+	Project string `json:"project,omitempty"`
+	// Task — задача в том виде, в каком она будет сохранена; заполняется только при предпросмотре
+	Task *Task `json:"task,omitempty"`
+}
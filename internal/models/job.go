@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job — фоновая задача планировщика, хранится в Mongo и переживает перезапуски
+type Job struct {
+	ID          primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	Type        string                 `json:"type" bson:"type"`
+	Key         string                 `json:"key,omitempty" bson:"key,omitempty"`
+	UserID      *primitive.ObjectID    `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	TaskID      *primitive.ObjectID    `json:"task_id,omitempty" bson:"task_id,omitempty"`
+	Payload     map[string]interface{} `json:"payload,omitempty" bson:"payload,omitempty"`
+	Status      string                 `json:"status" bson:"status"`
+	RunAt       time.Time              `json:"run_at" bson:"run_at"`
+	Interval    time.Duration          `json:"interval,omitempty" bson:"interval,omitempty"`
+	Attempts    int                    `json:"attempts" bson:"attempts"`
+	MaxAttempts int                    `json:"max_attempts" bson:"max_attempts"`
+	LastError   string                 `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	LeaseOwner  string                 `json:"-" bson:"lease_owner,omitempty"`
+	LeaseUntil  *time.Time             `json:"-" bson:"lease_until,omitempty"`
+	CreatedAt   time.Time              `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at" bson:"updated_at"`
+}
+
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Label struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WorkspaceID primitive.ObjectID `json:"workspace_id" bson:"workspace_id"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Name        string             `json:"name" bson:"name" validate:"required,max=50"`
+	Color       string             `json:"color" bson:"color" validate:"omitempty,hexcolor"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// TagUsage — тег и количество задач, в которых он используется
+type TagUsage struct {
+	Name  string `json:"name" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+	Color string `json:"color,omitempty" bson:"-"`
+}
@@ -7,21 +7,94 @@ import (
 )
 
 type Task struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
-	Title       string             `json:"title" bson:"title" validate:"required"`
-	Description string             `json:"description" bson:"description"`
-	Status      string             `json:"status" bson:"status" validate:"required,oneof=pending in_progress completed"`
-	Priority    string             `json:"priority" bson:"priority" validate:"required,oneof=low medium high"`
-	DueDate     *time.Time         `json:"due_date" bson:"due_date"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	ID                primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	WorkspaceID       primitive.ObjectID   `json:"workspace_id" bson:"workspace_id"`
+	UserID            primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	ProjectID         *primitive.ObjectID  `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	ParentID          *primitive.ObjectID  `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	AssigneeID        *primitive.ObjectID  `json:"assignee_id,omitempty" bson:"assignee_id,omitempty"`
+	ExternalID        string               `json:"external_id,omitempty" bson:"external_id,omitempty"`
+	Watchers          []primitive.ObjectID `json:"watchers,omitempty" bson:"watchers,omitempty"`
+	Ancestors         []primitive.ObjectID `json:"ancestors,omitempty" bson:"ancestors,omitempty"`
+	Title             string               `json:"title" bson:"title" validate:"required"`
+	Description       string               `json:"description" bson:"description"`
+	Status            string               `json:"status" bson:"status" validate:"required,oneof=pending in_progress completed"`
+	Priority          string               `json:"priority" bson:"priority" validate:"required,oneof=low medium high"`
+	DueDate           *time.Time           `json:"due_date" bson:"due_date"`
+	Tags              []string             `json:"tags" bson:"tags" validate:"dive,required,max=50"`
+	BlockedBy         []primitive.ObjectID `json:"blocked_by,omitempty" bson:"blocked_by,omitempty"`
+	Estimate          int                  `json:"estimate" bson:"estimate" validate:"min=0"`
+	Checklist         []ChecklistItem      `json:"checklist" bson:"checklist,omitempty" validate:"dive"`
+	ChecklistProgress int                  `json:"checklist_progress" bson:"-"`
+	Recurrence        string               `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	RecurrenceStart   *time.Time           `json:"recurrence_start,omitempty" bson:"recurrence_start,omitempty"`
+	Occurrence        int                  `json:"occurrence,omitempty" bson:"occurrence,omitempty"`
+	CreatedAt         time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at" bson:"updated_at"`
+	DeletedAt         *time.Time           `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedWith       *primitive.ObjectID  `json:"-" bson:"deleted_with,omitempty"`
+}
+
+type ChecklistItem struct {
+	ID   primitive.ObjectID `json:"id" bson:"id"`
+	Text string             `json:"text" bson:"text" validate:"required,max=500"`
+	Done bool               `json:"done" bson:"done"`
+}
+
+// CalcChecklistProgress заполняет ChecklistProgress — процент выполненных пунктов чеклиста.
+// Поле не хранится в базе и вычисляется при чтении.
+func (t *Task) CalcChecklistProgress() {
+	if len(t.Checklist) == 0 {
+		t.ChecklistProgress = 0
+		return
+	}
+	done := 0
+	for _, item := range t.Checklist {
+		if item.Done {
+			done++
+		}
+	}
+	t.ChecklistProgress = done * 100 / len(t.Checklist)
+}
+
+// TaskNode — задача вместе с вложенными подзадачами
+type TaskNode struct {
+	Task
+	Children []TaskNode `json:"children"`
+}
+
+// TaskSchedule — порядок выполнения задач с учётом зависимостей и критический путь
+type TaskSchedule struct {
+	Order         []primitive.ObjectID `json:"order"`
+	CriticalPath  []primitive.ObjectID `json:"critical_path"`
+	TotalEstimate int                  `json:"total_estimate"`
 }
 
 type User struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Username  string             `json:"username" bson:"username" validate:"required,min=3"`
-	Email     string             `json:"email" bson:"email" validate:"required,email"`
-	Password  string             `json:"-" bson:"password" validate:"required,min=6"`
-	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	ID                      primitive.ObjectID                `json:"id" bson:"_id,omitempty"`
+	Username                string                            `json:"username" bson:"username" validate:"required,min=3"`
+	Email                   string                            `json:"email" bson:"email" validate:"required,email"`
+	Password                string                            `json:"-" bson:"password" validate:"required,min=6"`
+	Timezone                string                            `json:"timezone" bson:"timezone" validate:"omitempty,timezone"`
+	NotificationPreferences map[string]NotificationPreference `json:"notification_preferences,omitempty" bson:"notification_preferences,omitempty"`
+	CalendarTokenHash       string                            `json:"-" bson:"calendar_token_hash,omitempty"`
+	CreatedAt               time.Time                         `json:"created_at" bson:"created_at"`
+}
+
+// Location возвращает часовой пояс пользователя, по умолчанию UTC
+func (u *User) Location() *time.Location {
+	if u.Timezone != "" {
+		if loc, err := time.LoadLocation(u.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// NotificationPreference возвращает настройки доставки уведомлений типа notificationType
+func (u *User) NotificationPreference(notificationType string) NotificationPreference {
+	if pref, ok := u.NotificationPreferences[notificationType]; ok {
+		return pref
+	}
+	return DefaultNotificationPreference
 }
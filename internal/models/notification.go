@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	NotificationTaskAssigned  = "task_assigned"
+	NotificationTaskCommented = "task_commented"
+	NotificationTaskUpdated   = "task_updated"
+	NotificationTaskDueSoon   = "task_due_soon"
+	NotificationTaskOverdue   = "task_overdue"
+)
+
+// NotificationTypes — все типы уведомлений, для которых можно задать настройки
+var NotificationTypes = []string{
+	NotificationTaskAssigned,
+	NotificationTaskCommented,
+	NotificationTaskUpdated,
+	NotificationTaskDueSoon,
+	NotificationTaskOverdue,
+}
+
+type Notification struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	Type      string              `json:"type" bson:"type"`
+	TaskID    *primitive.ObjectID `json:"task_id,omitempty" bson:"task_id,omitempty"`
+	Title     string              `json:"title" bson:"title"`
+	Body      string              `json:"body" bson:"body"`
+	Read      bool                `json:"read" bson:"read"`
+	ReadAt    *time.Time          `json:"read_at,omitempty" bson:"read_at,omitempty"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// NotificationPreference определяет, куда доставлять уведомления одного типа
+type NotificationPreference struct {
+	InApp bool `json:"in_app" bson:"in_app"`
+	Email bool `json:"email" bson:"email"`
+}
+
+// DefaultNotificationPreference — по умолчанию уведомления приходят только в приложение
+var DefaultNotificationPreference = NotificationPreference{InApp: true, Email: false}
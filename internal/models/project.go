@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Project struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WorkspaceID primitive.ObjectID `json:"workspace_id" bson:"workspace_id"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Name        string             `json:"name" bson:"name" validate:"required,max=100"`
+	Description string             `json:"description" bson:"description"`
+	Archived    bool               `json:"archived" bson:"archived"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// ProjectStats — количество задач проекта по статусам
+type ProjectStats struct {
+	ProjectID    primitive.ObjectID `json:"project_id"`
+	StatusCounts map[string]int     `json:"status_counts"`
+	Total        int                `json:"total"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskTemplate — шаблон для создания одной или нескольких связанных задач.
+// В названии, описании, тегах и пунктах чеклиста можно использовать переменные {{name}}.
+type TaskTemplate struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WorkspaceID primitive.ObjectID `json:"workspace_id" bson:"workspace_id"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Name        string             `json:"name" bson:"name" validate:"required,max=100"`
+	Description string             `json:"description" bson:"description"`
+	Tasks       []TemplateTask     `json:"tasks" bson:"tasks" validate:"required,min=1,max=50,dive"`
+	Variables   []string           `json:"variables" bson:"-"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// TemplateTask — задача шаблона. Key нужен, чтобы ссылаться на задачу из Parent и BlockedBy других задач шаблона.
+// DueOffset — срок относительно даты создания, например "+3d", "+1w", "+4h".
+type TemplateTask struct {
+	Key         string   `json:"key,omitempty" bson:"key,omitempty" validate:"omitempty,max=50"`
+	Title       string   `json:"title" bson:"title" validate:"required"`
+	Description string   `json:"description" bson:"description"`
+	Priority    string   `json:"priority" bson:"priority" validate:"omitempty,oneof=low medium high"`
+	DueOffset   string   `json:"due_offset,omitempty" bson:"due_offset,omitempty"`
+	Estimate    int      `json:"estimate" bson:"estimate" validate:"min=0"`
+	Tags        []string `json:"tags" bson:"tags" validate:"dive,required,max=50"`
+	Checklist   []string `json:"checklist" bson:"checklist" validate:"dive,required,max=500"`
+	Parent      string   `json:"parent,omitempty" bson:"parent,omitempty"`
+	BlockedBy   []string `json:"blocked_by,omitempty" bson:"blocked_by,omitempty"`
+}
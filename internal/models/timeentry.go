@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TimeEntry — отрезок времени, потраченный пользователем на задачу.
+// У запущенного таймера Running = true, EndedAt пуст, а Duration ещё не посчитан.
+type TimeEntry struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WorkspaceID primitive.ObjectID `json:"workspace_id" bson:"workspace_id"`
+	TaskID      primitive.ObjectID `json:"task_id" bson:"task_id"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	StartedAt   time.Time          `json:"started_at" bson:"started_at"`
+	EndedAt     *time.Time         `json:"ended_at" bson:"ended_at"`
+	// Duration — длительность в секундах
+	Duration  int64     `json:"duration" bson:"duration"`
+	Note      string    `json:"note" bson:"note" validate:"max=500"`
+	Manual    bool      `json:"manual" bson:"manual"`
+	Running   bool      `json:"running" bson:"running,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// TimesheetEntry — запись табеля с данными задачи, проекта и пользователя
+type TimesheetEntry struct {
+	TimeEntry `bson:",inline"`
+	TaskTitle string              `json:"task_title" bson:"task_title"`
+	ProjectID *primitive.ObjectID `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	Project   string              `json:"project,omitempty" bson:"-"`
+	User      string              `json:"user" bson:"-"`
+	// Date — день начала записи в часовом поясе того, кто запросил табель
+	Date string `json:"date" bson:"-"`
+}
+
+// TimeTotal — суммарное время по задаче, проекту, пользователю или дню
+type TimeTotal struct {
+	Key      string `json:"key"`
+	Name     string `json:"name,omitempty"`
+	Duration int64  `json:"duration"`
+}
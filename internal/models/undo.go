@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	UndoTaskDelete  = "task.delete"
+	UndoTaskBulk    = "task.bulk"
+	UndoLabelMerge  = "label.merge"
+	UndoLabelDelete = "label.delete"
+)
+
+// UndoAction — обратная операция, которую можно выполнить по токену отмены
+// в течение короткого окна после исходной операции
+type UndoAction struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WorkspaceID primitive.ObjectID `json:"workspace_id" bson:"workspace_id"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Operation   string             `json:"operation" bson:"operation"`
+	TokenHash   string             `json:"-" bson:"token_hash"`
+	Payload     bson.Raw           `json:"-" bson:"payload"`
+	ExpiresAt   time.Time          `json:"expires_at" bson:"expires_at"`
+	UsedAt      *time.Time         `json:"used_at,omitempty" bson:"used_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// LabelUndo — состояние меток и тегов задач до слияния или удаления меток
+type LabelUndo struct {
+	Labels []Label `bson:"labels"`
+	// Tagged — задачи, у которых был тег каждой из удалённых меток
+	Tagged map[string][]primitive.ObjectID `bson:"tagged"`
+	// TargetTag и Retagged — тег целевой метки слияния и задачи, получившие его при слиянии
+	TargetTag string               `bson:"target_tag,omitempty"`
+	Retagged  []primitive.ObjectID `bson:"retagged,omitempty"`
+}
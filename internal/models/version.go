@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskVersion — полный снимок задачи после очередного изменения.
+// Версии нумеруются с 1 отдельно для каждой задачи.
+type TaskVersion struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	TaskID      primitive.ObjectID  `json:"task_id" bson:"task_id"`
+	WorkspaceID primitive.ObjectID  `json:"workspace_id" bson:"workspace_id"`
+	Version     int                 `json:"version" bson:"version"`
+	Action      string              `json:"action" bson:"action"`
+	ActorID     *primitive.ObjectID `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+	Task        Task                `json:"task" bson:"task"`
+	CreatedAt   time.Time           `json:"created_at" bson:"created_at"`
+}
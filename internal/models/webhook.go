@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook — адрес, на который отправляются события задач рабочего пространства
+type Webhook struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WorkspaceID primitive.ObjectID `json:"workspace_id" bson:"workspace_id"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	URL         string             `json:"url" bson:"url" validate:"required,http_url,max=2048"`
+	Events      []string           `json:"events" bson:"events" validate:"required,min=1,dive,oneof=task.created task.updated task.deleted"`
+	Secret      string             `json:"-" bson:"secret"`
+	Active      bool               `json:"active" bson:"active"`
+	Failures    int                `json:"failures" bson:"failures"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// WebhookDelivery — попытка доставки события на webhook и её результат
+type WebhookDelivery struct {
+	ID             primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	WebhookID      primitive.ObjectID  `json:"webhook_id" bson:"webhook_id"`
+	WorkspaceID    primitive.ObjectID  `json:"workspace_id" bson:"workspace_id"`
+	Key            string              `json:"-" bson:"key"`
+	EventID        string              `json:"event_id" bson:"event_id"`
+	Event          string              `json:"event" bson:"event"`
+	Payload        string              `json:"payload" bson:"payload"`
+	RedeliveryOf   *primitive.ObjectID `json:"redelivery_of,omitempty" bson:"redelivery_of,omitempty"`
+	Status         string              `json:"status" bson:"status"`
+	Attempts       int                 `json:"attempts" bson:"attempts"`
+	ResponseStatus int                 `json:"response_status,omitempty" bson:"response_status,omitempty"`
+	ResponseBody   string              `json:"response_body,omitempty" bson:"response_body,omitempty"`
+	Error          string              `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt      time.Time           `json:"created_at" bson:"created_at"`
+	DeliveredAt    *time.Time          `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+var roleRank = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// RoleAtLeast проверяет, что роль role даёт не меньше прав, чем min
+func RoleAtLeast(role, min string) bool {
+	return roleRank[role] > 0 && roleRank[role] >= roleRank[min]
+}
+
+// Workspace — рабочее пространство, которому принадлежат задачи, проекты и метки.
+// У каждого пользователя есть личное пространство (Personal), созданное автоматически.
+type Workspace struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name" validate:"required,max=100"`
+	OwnerID   primitive.ObjectID `json:"owner_id" bson:"owner_id"`
+	Personal  bool               `json:"personal" bson:"personal"`
+	Members   []WorkspaceMember  `json:"members" bson:"members"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type WorkspaceMember struct {
+	UserID   primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Role     string             `json:"role" bson:"role"`
+	JoinedAt time.Time          `json:"joined_at" bson:"joined_at"`
+}
+
+// Role возвращает роль пользователя в пространстве или пустую строку, если он не участник
+func (w *Workspace) Role(userID primitive.ObjectID) string {
+	for _, m := range w.Members {
+		if m.UserID == userID {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// Invite — приглашение в рабочее пространство по email. Хранится только хеш токена.
+type Invite struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	WorkspaceID primitive.ObjectID  `json:"workspace_id" bson:"workspace_id"`
+	Email       string              `json:"email" bson:"email" validate:"required,email"`
+	Role        string              `json:"role" bson:"role" validate:"required,oneof=editor viewer"`
+	TokenHash   string              `json:"-" bson:"token_hash"`
+	InvitedBy   primitive.ObjectID  `json:"invited_by" bson:"invited_by"`
+	ExpiresAt   time.Time           `json:"expires_at" bson:"expires_at"`
+	AcceptedBy  *primitive.ObjectID `json:"accepted_by,omitempty" bson:"accepted_by,omitempty"`
+	AcceptedAt  *time.Time          `json:"accepted_at,omitempty" bson:"accepted_at,omitempty"`
+	CreatedAt   time.Time           `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"task_manager/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Dispatcher доставляет уведомление в приложение и/или на почту
+// в соответствии с настройками пользователя для типа уведомления
+type Dispatcher struct {
+	users *mongo.Collection
+	inApp Notifier
+	email EmailSender
+}
+
+func NewDispatcher(users *mongo.Collection, inApp Notifier, email EmailSender) *Dispatcher {
+	return &Dispatcher{users: users, inApp: inApp, email: email}
+}
+
+func (d *Dispatcher) Notify(ctx context.Context, msg Message) error {
+	ur := repositories.NewUserRepository(d.users)
+	user, err := ur.GetUser(msg.UserID, ctx)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+
+	pref := user.NotificationPreference(msg.Type)
+	var errs []error
+	if pref.InApp {
+		errs = append(errs, d.inApp.Notify(ctx, msg))
+	}
+	if pref.Email {
+		errs = append(errs, d.email.Send(ctx, user.Email, msg.Title, msg.Body))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"task_manager/internal/config"
+	"time"
+)
+
+// EmailSender отправляет письмо на адрес to
+type EmailSender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// NewEmailSender возвращает SMTP-отправителя или, если SMTP не настроен, отправителя в лог
+func NewEmailSender(cfg *config.Config) EmailSender {
+	if cfg.SMTPHost == "" {
+		return logEmailSender{}
+	}
+	return &SMTPSender{
+		addr:     cfg.SMTPHost + ":" + cfg.SMTPPort,
+		host:     cfg.SMTPHost,
+		user:     cfg.SMTPUser,
+		password: cfg.SMTPPassword,
+		from:     cfg.SMTPFrom,
+		timeout:  cfg.SMTPTimeout,
+	}
+}
+
+type SMTPSender struct {
+	addr     string
+	host     string
+	user     string
+	password string
+	from     string
+	timeout  time.Duration
+}
+
+// Send отправляет письмо, укладываясь в срок ctx и таймаут SMTP: медленный или недоступный
+// сервер не должен задерживать запросы, из которых отправляются уведомления
+func (s *SMTPSender) Send(ctx context.Context, to, subject, body string) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	// Отмена ctx прерывает разговор с сервером, даже если срок не задан
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.user != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.user, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	// Переводы строк в заголовках недопустимы
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		s.from, to, subject, body)
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+type logEmailSender struct{}
+
+func (logEmailSender) Send(ctx context.Context, to, subject, body string) error {
+	return NewLogNotifier().Notify(ctx, Message{Type: "email", Title: subject, Body: to + ": " + body})
+}
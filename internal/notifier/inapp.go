@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"context"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// InAppNotifier сохраняет уведомления в центр уведомлений пользователя
+type InAppNotifier struct {
+	notifications *mongo.Collection
+}
+
+func NewInAppNotifier(notifications *mongo.Collection) *InAppNotifier {
+	return &InAppNotifier{notifications: notifications}
+}
+
+func (n *InAppNotifier) Notify(ctx context.Context, msg Message) error {
+	r := repositories.NewNotificationRepository(n.notifications)
+	_, err := r.CreateNotification(&models.Notification{
+		UserID: msg.UserID,
+		Type:   msg.Type,
+		TaskID: msg.TaskID,
+		Title:  msg.Title,
+		Body:   msg.Body,
+	}, ctx)
+	return err
+}
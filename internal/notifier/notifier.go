@@ -0,0 +1,41 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Message — уведомление пользователю о событии задачи
+type Message struct {
+	UserID primitive.ObjectID
+	TaskID *primitive.ObjectID
+	Type   string
+	Title  string
+	Body   string
+}
+
+// Notifier доставляет уведомления пользователю
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// LogNotifier пишет уведомления в лог; используется, когда доставка не настроена
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	event := log.Info().
+		Str("user_id", msg.UserID.Hex()).
+		Str("type", msg.Type).
+		Str("title", msg.Title)
+	if msg.TaskID != nil {
+		event = event.Str("task_id", msg.TaskID.Hex())
+	}
+	event.Msg(msg.Body)
+	return nil
+}
@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ActivityCollectionName — коллекция истории задач; в неё пишет TaskRepository
+// в той же транзакции, что и само изменение
+const ActivityCollectionName = "task_activity"
+
+// Поля, которые не попадают в историю: служебные или вычисляемые из других
+var activityIgnoredFields = map[string]bool{
+	"_id":          true,
+	"workspace_id": true,
+	"ancestors":    true,
+	"deleted_with": true,
+	"created_at":   true,
+	"updated_at":   true,
+}
+
+type actorKey struct{}
+
+// WithActor запоминает в контексте пользователя, от имени которого выполняются изменения
+func WithActor(ctx context.Context, userID primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+func actorFrom(ctx context.Context) *primitive.ObjectID {
+	if userID, ok := ctx.Value(actorKey{}).(primitive.ObjectID); ok {
+		return &userID
+	}
+	return nil
+}
+
+func NewActivityRepository(db *mongo.Collection) *ActivityRepository {
+	return &ActivityRepository{db: db}
+}
+
+type ActivityRepository struct {
+	db *mongo.Collection
+}
+
+func (a *ActivityRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := a.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+// GetTaskHistory возвращает страницу истории задачи, новые записи первыми.
+// История доступна и после удаления задачи.
+func (a *ActivityRepository) GetTaskHistory(taskID, workspaceID primitive.ObjectID, page, limit int, ctx context.Context) ([]models.Activity, int64, error) {
+	return a.find(bson.M{"task_id": taskID, "workspace_id": workspaceID}, page, limit, ctx)
+}
+
+// GetUserActivity возвращает изменения, сделанные пользователем в указанных рабочих пространствах
+func (a *ActivityRepository) GetUserActivity(userID primitive.ObjectID, workspaceIDs []primitive.ObjectID, page, limit int, ctx context.Context) ([]models.Activity, int64, error) {
+	return a.find(bson.M{"actor_id": userID, "workspace_id": bson.M{"$in": workspaceIDs}}, page, limit, ctx)
+}
+
+func (a *ActivityRepository) find(filter bson.M, page, limit int, ctx context.Context) ([]models.Activity, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	total, err := a.db.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	activities := []models.Activity{}
+	cursor, err := a.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &activities); err != nil {
+		return nil, 0, err
+	}
+	return activities, total, nil
+}
+
+// track выполняет изменение задач в транзакции и записывает в историю diff каждой задачи,
+// подходившей под filter до изменения (или после — для новых задач)
+func (t *TaskRepository) track(ctx context.Context, filter bson.M, fn func(sc mongo.SessionContext) error) error {
+	return withTransaction(ctx, t.db.Database(), func(sc mongo.SessionContext) error {
+		before, err := t.snapshot(sc, filter)
+		if err != nil {
+			return err
+		}
+		if err := fn(sc); err != nil {
+			return err
+		}
+		// Изменённые задачи могут перестать подходить под фильтр, а новые — начать
+		afterFilter := filter
+		if len(before) > 0 {
+			ids := make([]primitive.ObjectID, 0, len(before))
+			for id := range before {
+				ids = append(ids, id)
+			}
+			afterFilter = bson.M{"$or": bson.A{bson.M{"_id": bson.M{"$in": ids}}, filter}}
+		}
+		after, err := t.snapshot(sc, afterFilter)
+		if err != nil {
+			return err
+		}
+
+		activities := buildActivities(before, after, actorFrom(ctx), time.Now())
+		if len(activities) == 0 {
+			return nil
+		}
+		docs := make([]interface{}, len(activities))
+		for i := range activities {
+			docs[i] = activities[i]
+		}
+		if _, err = t.db.Database().Collection(ActivityCollectionName).InsertMany(sc, docs); err != nil {
+			return err
+		}
+		return t.saveVersions(sc, activities, after)
+	})
+}
+
+// trackedUpdate — UpdateOne с записью в историю
+func (t *TaskRepository) trackedUpdate(ctx context.Context, filter, update bson.M) (*mongo.UpdateResult, error) {
+	var result *mongo.UpdateResult
+	err := t.track(ctx, filter, func(sc mongo.SessionContext) error {
+		var err error
+		result, err = t.db.UpdateOne(sc, filter, update)
+		return err
+	})
+	return result, err
+}
+
+func (t *TaskRepository) snapshot(ctx context.Context, filter bson.M) (map[primitive.ObjectID]bson.M, error) {
+	cursor, err := t.db.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var docs []bson.M
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	snapshot := make(map[primitive.ObjectID]bson.M, len(docs))
+	for _, doc := range docs {
+		if id, ok := doc["_id"].(primitive.ObjectID); ok {
+			snapshot[id] = doc
+		}
+	}
+	return snapshot, nil
+}
+
+func buildActivities(before, after map[primitive.ObjectID]bson.M, actorID *primitive.ObjectID, now time.Time) []models.Activity {
+	var activities []models.Activity
+	record := func(id primitive.ObjectID, action string, old, next bson.M) {
+		changes := diffDocuments(old, next)
+		if action == models.ActivityUpdated && len(changes) == 0 {
+			return
+		}
+		doc := next
+		if doc == nil {
+			doc = old
+		}
+		workspaceID, _ := doc["workspace_id"].(primitive.ObjectID)
+		title, _ := doc["title"].(string)
+		activities = append(activities, models.Activity{
+			WorkspaceID: workspaceID,
+			TaskID:      id,
+			TaskTitle:   title,
+			ActorID:     actorID,
+			Action:      action,
+			Changes:     changes,
+			CreatedAt:   now,
+		})
+	}
+	for id, old := range before {
+		next, ok := after[id]
+		_, wasDeleted := old["deleted_at"]
+		_, isDeleted := next["deleted_at"]
+		switch {
+		case !ok:
+			record(id, models.ActivityPurged, old, nil)
+		case !wasDeleted && isDeleted:
+			record(id, models.ActivityDeleted, old, next)
+		case wasDeleted && !isDeleted:
+			record(id, models.ActivityRestored, old, next)
+		default:
+			record(id, models.ActivityUpdated, old, next)
+		}
+	}
+	for id, next := range after {
+		if _, ok := before[id]; !ok {
+			record(id, models.ActivityCreated, nil, next)
+		}
+	}
+	return activities
+}
+
+// diffDocuments сравнивает документы по полям верхнего уровня
+func diffDocuments(old, next bson.M) []models.FieldChange {
+	fields := map[string]bool{}
+	for field := range old {
+		fields[field] = true
+	}
+	for field := range next {
+		fields[field] = true
+	}
+	changes := []models.FieldChange{}
+	for field := range fields {
+		if activityIgnoredFields[field] {
+			continue
+		}
+		if !reflect.DeepEqual(old[field], next[field]) {
+			changes = append(changes, models.FieldChange{Field: field, Old: old[field], New: next[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewAttachmentRepository(db *mongo.Collection) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+type AttachmentRepository struct {
+	db *mongo.Collection
+}
+
+func (a *AttachmentRepository) CreateAttachment(attachment *models.Attachment, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	attachment.CreatedAt = time.Now()
+	result, err := a.db.InsertOne(ctx, attachment)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (a *AttachmentRepository) GetAttachments(taskIDs []primitive.ObjectID, ctx context.Context) ([]models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	attachments := []models.Attachment{}
+	cursor, err := a.db.Find(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (a *AttachmentRepository) GetAttachment(attachmentID primitive.ObjectID, ctx context.Context) (*models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var attachment models.Attachment
+	err := a.db.FindOne(ctx, bson.M{"_id": attachmentID}).Decode(&attachment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (a *AttachmentRepository) DeleteAttachments(attachmentIDs []primitive.ObjectID, ctx context.Context) (*mongo.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	result, err := a.db.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": attachmentIDs}})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindOrphans возвращает вложения, задачи которых больше не существуют
+func (a *AttachmentRepository) FindOrphans(taskCollection string, ctx context.Context) ([]models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	pipeline := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{"from": taskCollection, "localField": "task_id", "foreignField": "_id", "as": "task"}}},
+		{{Key: "$match", Value: bson.M{"task": bson.M{"$size": 0}}}},
+		{{Key: "$project", Value: bson.M{"task": 0}}},
+	}
+	cursor, err := a.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	attachments := []models.Attachment{}
+	if err = cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
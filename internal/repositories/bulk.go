@@ -0,0 +1,254 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BulkApply применяет операцию к задачам taskIDs одним BulkWrite и возвращает результат по каждой задаче,
+// идентификаторы всех изменённых задач (при удалении — вместе с подзадачами) и данные для отмены.
+// В режиме dryRun ничего не записывается, но результаты считаются так же.
+func (t *TaskRepository) BulkApply(workspaceID primitive.ObjectID, taskIDs []primitive.ObjectID, op models.BulkOperation, dryRun bool, ctx context.Context) ([]models.BulkResult, []primitive.ObjectID, *models.BulkUndo, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+
+	var tasks []models.Task
+	cursor, err := t.db.Find(ctx, notDeleted(bson.M{"_id": bson.M{"$in": taskIDs}, "workspace_id": workspaceID}))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, nil, nil, err
+	}
+	byID := make(map[primitive.ObjectID]*models.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	var blocked map[primitive.ObjectID]bool
+	if op.Type == models.BulkSetStatus && op.Status == "completed" && cfg.BlockCompletion {
+		if blocked, err = t.openBlockersOf(tasks, workspaceID, ctx); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	now := time.Now()
+	results := make([]models.BulkResult, 0, len(taskIDs))
+	undo := &models.BulkUndo{Type: op.Type, Tag: op.Tag}
+	var writes []mongo.WriteModel
+	var changed []primitive.ObjectID
+	seen := make(map[primitive.ObjectID]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		task, ok := byID[id]
+		if !ok {
+			results = append(results, models.BulkResult{ID: id, Result: models.BulkNotFound})
+			continue
+		}
+
+		filter := bson.M{"_id": id, "workspace_id": workspaceID}
+		var update bson.M
+		var old interface{}
+		switch op.Type {
+		case models.BulkSetStatus:
+			if task.Status != op.Status {
+				if blocked[id] {
+					results = append(results, models.BulkResult{ID: id, Result: models.BulkBlocked})
+					continue
+				}
+				update, old = bson.M{"$set": bson.M{"status": op.Status, "updated_at": now}}, task.Status
+			}
+		case models.BulkSetPriority:
+			if task.Priority != op.Priority {
+				update, old = bson.M{"$set": bson.M{"priority": op.Priority, "updated_at": now}}, task.Priority
+			}
+		case models.BulkAddTag:
+			if !containsTag(task.Tags, op.Tag) {
+				update = bson.M{"$addToSet": bson.M{"tags": op.Tag}, "$set": bson.M{"updated_at": now}}
+			}
+		case models.BulkMoveProject:
+			if !sameProject(task.ProjectID, op.ProjectID) {
+				update = setProject(op.ProjectID, now)
+				if task.ProjectID != nil {
+					old = *task.ProjectID
+				}
+			}
+		case models.BulkDelete:
+			// Подзадачи попадают в корзину вместе с задачей, как при обычном удалении
+			filter = notDeleted(bson.M{"workspace_id": workspaceID, "$or": bson.A{bson.M{"_id": id}, bson.M{"ancestors": id}}})
+			update = bson.M{"$set": bson.M{"deleted_at": now, "deleted_with": id}}
+		default:
+			return nil, nil, nil, fmt.Errorf("unknown bulk operation %q", op.Type)
+		}
+
+		if update == nil {
+			results = append(results, models.BulkResult{ID: id, Result: models.BulkUnchanged})
+			continue
+		}
+		result := models.BulkResult{ID: id, Result: models.BulkUpdated}
+		if op.Type == models.BulkDelete {
+			result.Result = models.BulkDeleted
+			writes = append(writes, mongo.NewUpdateManyModel().SetFilter(filter).SetUpdate(update))
+		} else {
+			writes = append(writes, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update))
+		}
+		results = append(results, result)
+		changed = append(changed, id)
+		undo.Items = append(undo.Items, models.BulkUndoItem{TaskID: id, Old: old})
+	}
+	if dryRun || len(writes) == 0 {
+		return results, changed, undo, nil
+	}
+
+	tracked := notDeleted(bson.M{"workspace_id": workspaceID, "_id": bson.M{"$in": changed}})
+	if op.Type == models.BulkDelete {
+		tracked = notDeleted(bson.M{"workspace_id": workspaceID, "$or": bson.A{
+			bson.M{"_id": bson.M{"$in": changed}},
+			bson.M{"ancestors": bson.M{"$in": changed}},
+		}})
+	}
+	affected := changed
+	err = t.track(ctx, tracked, func(sc mongo.SessionContext) error {
+		if op.Type == models.BulkDelete {
+			ids, err := distinctIDs(sc, t.db, tracked)
+			if err != nil {
+				return err
+			}
+			affected = ids
+		}
+		_, err := t.db.BulkWrite(sc, writes, options.BulkWrite().SetOrdered(true))
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return results, affected, undo, nil
+}
+
+// UndoBulk возвращает задачам значения, которые были у них до массовой операции,
+// и возвращает идентификаторы изменённых задач
+func (t *TaskRepository) UndoBulk(workspaceID primitive.ObjectID, undo *models.BulkUndo, ctx context.Context) ([]primitive.ObjectID, error) {
+	if undo.Type == models.BulkDelete {
+		var restored []primitive.ObjectID
+		for _, item := range undo.Items {
+			ids, err := t.RestoreTask(workspaceID, item.TaskID, ctx)
+			// Задачу могли уже восстановить вручную
+			if err == ErrNotInTrash {
+				continue
+			}
+			if err != nil {
+				return restored, err
+			}
+			restored = append(restored, ids...)
+		}
+		return restored, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(undo.Items))
+	ids := make([]primitive.ObjectID, 0, len(undo.Items))
+	for _, item := range undo.Items {
+		var update bson.M
+		switch undo.Type {
+		case models.BulkSetStatus:
+			update = bson.M{"$set": bson.M{"status": item.Old, "updated_at": now}}
+		case models.BulkSetPriority:
+			update = bson.M{"$set": bson.M{"priority": item.Old, "updated_at": now}}
+		case models.BulkMoveProject:
+			var projectID *primitive.ObjectID
+			if id, ok := item.Old.(primitive.ObjectID); ok {
+				projectID = &id
+			}
+			update = setProject(projectID, now)
+		case models.BulkAddTag:
+			update = bson.M{"$pull": bson.M{"tags": undo.Tag}, "$set": bson.M{"updated_at": now}}
+		default:
+			return nil, fmt.Errorf("unknown bulk operation %q", undo.Type)
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(notDeleted(bson.M{"_id": item.TaskID, "workspace_id": workspaceID})).
+			SetUpdate(update))
+		ids = append(ids, item.TaskID)
+	}
+	if len(writes) == 0 {
+		return nil, nil
+	}
+	err := t.track(ctx, notDeleted(bson.M{"_id": bson.M{"$in": ids}, "workspace_id": workspaceID}), func(sc mongo.SessionContext) error {
+		_, err := t.db.BulkWrite(sc, writes)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// openBlockersOf отмечает задачи, у которых есть незавершённые блокирующие задачи.
+// Блокирующие задачи из того же набора считаются завершёнными.
+func (t *TaskRepository) openBlockersOf(tasks []models.Task, workspaceID primitive.ObjectID, ctx context.Context) (map[primitive.ObjectID]bool, error) {
+	inBatch := make(map[primitive.ObjectID]bool, len(tasks))
+	var blockerIDs []primitive.ObjectID
+	for _, task := range tasks {
+		inBatch[task.ID] = true
+		blockerIDs = append(blockerIDs, task.BlockedBy...)
+	}
+	blocked := map[primitive.ObjectID]bool{}
+	if len(blockerIDs) == 0 {
+		return blocked, nil
+	}
+	open, err := distinctIDs(ctx, t.db, notDeleted(bson.M{"_id": bson.M{"$in": blockerIDs}, "workspace_id": workspaceID, "status": bson.M{"$ne": "completed"}}))
+	if err != nil {
+		return nil, err
+	}
+	openSet := make(map[primitive.ObjectID]bool, len(open))
+	for _, id := range open {
+		if !inBatch[id] {
+			openSet[id] = true
+		}
+	}
+	for _, task := range tasks {
+		for _, blocker := range task.BlockedBy {
+			if openSet[blocker] {
+				blocked[task.ID] = true
+			}
+		}
+	}
+	return blocked, nil
+}
+
+// setProject переносит задачу в проект; nil — задача без проекта
+func setProject(projectID *primitive.ObjectID, now time.Time) bson.M {
+	if projectID == nil {
+		return bson.M{"$unset": bson.M{"project_id": ""}, "$set": bson.M{"updated_at": now}}
+	}
+	return bson.M{"$set": bson.M{"project_id": *projectID, "updated_at": now}}
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func sameProject(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewCommentRepository(db *mongo.Collection) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+type CommentRepository struct {
+	db *mongo.Collection
+}
+
+func (r *CommentRepository) CreateComment(comment *models.Comment, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = comment.CreatedAt
+	if comment.History == nil {
+		comment.History = []models.CommentRevision{}
+	}
+	result, err := r.db.InsertOne(ctx, comment)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetComments возвращает страницу комментариев задачи в порядке создания и их общее количество
+func (r *CommentRepository) GetComments(taskID primitive.ObjectID, page, limit int, ctx context.Context) ([]models.Comment, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	filter := bson.M{"task_id": taskID}
+	total, err := r.db.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	comments := []models.Comment{}
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &comments); err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+func (r *CommentRepository) GetComment(commentID primitive.ObjectID, ctx context.Context) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var comment models.Comment
+	err := r.db.FindOne(ctx, bson.M{"_id": commentID}).Decode(&comment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("comment not found")
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// UpdateComment меняет текст комментария автора, сохраняя предыдущий текст в истории
+func (r *CommentRepository) UpdateComment(commentID, userID primitive.ObjectID, body string, mentions []primitive.ObjectID, ctx context.Context) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	current, err := r.GetComment(commentID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current.UserID != userID {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	now := time.Now()
+	revision := models.CommentRevision{Body: current.Body, EditedAt: now}
+	update := bson.M{
+		"$set":  bson.M{"body": body, "mentions": mentions, "updated_at": now},
+		"$push": bson.M{"history": revision},
+	}
+	var comment models.Comment
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.db.FindOneAndUpdate(ctx, bson.M{"_id": commentID, "user_id": userID, "body": current.Body}, update, opts).Decode(&comment)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *CommentRepository) DeleteComment(commentID primitive.ObjectID, ctx context.Context) (*mongo.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	result, err := r.db.DeleteOne(ctx, bson.M{"_id": commentID})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteTaskComments удаляет комментарии удалённых задач
+func (r *CommentRepository) DeleteTaskComments(taskIDs []primitive.ObjectID, ctx context.Context) (*mongo.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	result, err := r.db.DeleteMany(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
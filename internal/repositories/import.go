@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (t *TaskRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	_, err := t.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "external_id", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"external_id": bson.M{"$type": "string"}}),
+	})
+	return err
+}
+
+// ExportTasks передаёт fn задачи рабочего пространства по одной, не загружая их все в память.
+// Время выгрузки не ограничивается cfg.ContextTimeout: она прерывается ошибкой fn или отменой ctx.
+func (t *TaskRepository) ExportTasks(workspaceID primitive.ObjectID, filter TaskFilter, fn func(*models.Task) error, ctx context.Context) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := t.db.Find(ctx, notDeleted(filter.apply(bson.M{"workspace_id": workspaceID})), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var task models.Task
+		if err := cursor.Decode(&task); err != nil {
+			return err
+		}
+		task.CalcChecklistProgress()
+		if err := fn(&task); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// ImportTasks создаёт задачи или обновляет ранее импортированные с тем же external_id.
+// Задача этого же приложения узнаётся и по своему ID, поэтому повторный импорт выгрузки не создаёт дубликатов.
+// Результаты возвращаются в порядке items; номера строк заполняет вызывающий.
+// В режиме dryRun результаты считаются так же, но ничего не записывается.
+func (t *TaskRepository) ImportTasks(workspaceID, userID primitive.ObjectID, items []models.ImportItem, dryRun bool, ctx context.Context) ([]models.ImportResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+
+	existing, err := t.findImported(workspaceID, items, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]models.ImportResult, len(items))
+	var writes []mongo.WriteModel
+	var ids []primitive.ObjectID
+	for i := range items {
+		item := &items[i]
+		results[i].ExternalID = item.Task.ExternalID
+		current, found := existing[item.Task.ExternalID]
+		if found && current.DeletedAt != nil {
+			results[i].Result, results[i].Error = models.ImportFailed, "task is in trash"
+			continue
+		}
+		if !found {
+			task := item.Task
+			task.ID = primitive.NewObjectID()
+			task.WorkspaceID = workspaceID
+			task.UserID = userID
+			task.CreatedAt, task.UpdatedAt = now, now
+			writes = append(writes, mongo.NewInsertOneModel().SetDocument(&task))
+			ids = append(ids, task.ID)
+			results[i].Result = models.ImportCreated
+			if !dryRun {
+				results[i].TaskID = &task.ID
+			}
+			continue
+		}
+
+		doc, err := toDocument(&item.Task)
+		if err != nil {
+			return nil, err
+		}
+		set := bson.M{"updated_at": now}
+		unset := bson.M{}
+		for _, field := range item.Fields {
+			// Пустые необязательные поля с omitempty в документ не попадают — значит, их нужно убрать
+			if value, ok := doc[field]; ok {
+				set[field] = value
+			} else {
+				unset[field] = ""
+			}
+		}
+		// Ранее созданная здесь задача получает external_id, чтобы дальше находиться по нему
+		set["external_id"] = item.Task.ExternalID
+		update := bson.M{"$set": set}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": current.ID, "workspace_id": workspaceID}).
+			SetUpdate(update))
+		ids = append(ids, current.ID)
+		id := current.ID
+		results[i].TaskID, results[i].Result = &id, models.ImportUpdated
+	}
+	if dryRun || len(writes) == 0 {
+		return results, nil
+	}
+
+	err = t.track(ctx, bson.M{"_id": bson.M{"$in": ids}}, func(sc mongo.SessionContext) error {
+		_, err := t.db.BulkWrite(sc, writes, options.BulkWrite().SetOrdered(true))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// findImported находит уже существующие задачи по external_id или по собственному ID, включая задачи в корзине
+func (t *TaskRepository) findImported(workspaceID primitive.ObjectID, items []models.ImportItem, ctx context.Context) (map[string]*models.Task, error) {
+	externalIDs := make([]string, 0, len(items))
+	var ownIDs []primitive.ObjectID
+	for _, item := range items {
+		externalIDs = append(externalIDs, item.Task.ExternalID)
+		if id, err := primitive.ObjectIDFromHex(item.Task.ExternalID); err == nil {
+			ownIDs = append(ownIDs, id)
+		}
+	}
+	filter := bson.M{"workspace_id": workspaceID, "$or": bson.A{
+		bson.M{"external_id": bson.M{"$in": externalIDs}},
+		bson.M{"_id": bson.M{"$in": ownIDs}},
+	}}
+	var tasks []models.Task
+	cursor, err := t.db.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]*models.Task, len(tasks))
+	for i := range tasks {
+		if tasks[i].ExternalID == "" {
+			found[tasks[i].ID.Hex()] = &tasks[i]
+		}
+	}
+	// Совпадение по external_id важнее совпадения по ID
+	for i := range tasks {
+		if tasks[i].ExternalID != "" {
+			found[tasks[i].ExternalID] = &tasks[i]
+		}
+	}
+	return found, nil
+}
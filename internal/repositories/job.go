@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewJobRepository(db *mongo.Collection) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+type JobRepository struct {
+	db *mongo.Collection
+}
+
+// EnsureIndexes создаёт уникальный индекс по ключу, чтобы несколько экземпляров
+// приложения не ставили одну и ту же задачу дважды
+func (j *JobRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	_, err := j.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.M{"key": 1},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"key": bson.M{"$type": "string"}}),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}}},
+	})
+	return err
+}
+
+// Schedule ставит задачу в очередь. Если у задачи есть ключ и задача с таким ключом
+// уже существует, повторно она не создаётся.
+func (j *JobRepository) Schedule(job *models.Job, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	now := time.Now()
+	job.Status = models.JobPending
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = 1
+	}
+	if job.Key == "" {
+		result, err := j.db.InsertOne(ctx, job)
+		if err != nil {
+			return err
+		}
+		job.ID = result.InsertedID.(primitive.ObjectID)
+		return nil
+	}
+
+	_, err := j.db.UpdateOne(ctx, bson.M{"key": job.Key}, bson.M{"$setOnInsert": job}, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// Claim захватывает одну готовую к запуску задачу с арендой на lease.
+// Задачи, аренда которых истекла (экземпляр упал во время выполнения), захватываются повторно.
+func (j *JobRepository) Claim(owner string, lease time.Duration, ctx context.Context) (*models.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	now := time.Now()
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.JobPending, "run_at": bson.M{"$lte": now}},
+		bson.M{"status": models.JobRunning, "lease_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{"status": models.JobRunning, "lease_owner": owner, "lease_until": now.Add(lease), "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"run_at": 1}).SetReturnDocument(options.After)
+	var job models.Job
+	err := j.db.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Complete завершает задачу; периодическая задача возвращается в очередь на следующий запуск
+func (j *JobRepository) Complete(job *models.Job, owner string, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	now := time.Now()
+	set := bson.M{"status": models.JobDone, "updated_at": now, "last_error": ""}
+	if job.Interval > 0 {
+		set = bson.M{"status": models.JobPending, "run_at": now.Add(job.Interval), "attempts": 0, "updated_at": now, "last_error": ""}
+	}
+	_, err := j.db.UpdateOne(ctx, bson.M{"_id": job.ID, "lease_owner": owner},
+		bson.M{"$set": set, "$unset": bson.M{"lease_owner": "", "lease_until": ""}})
+	return err
+}
+
+// Fail фиксирует ошибку; если retryAt задан, задача будет перезапущена в это время
+func (j *JobRepository) Fail(job *models.Job, owner string, jobErr error, retryAt *time.Time, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	set := bson.M{"status": models.JobFailed, "last_error": jobErr.Error(), "updated_at": time.Now()}
+	if retryAt != nil {
+		set["status"] = models.JobPending
+		set["run_at"] = *retryAt
+	}
+	_, err := j.db.UpdateOne(ctx, bson.M{"_id": job.ID, "lease_owner": owner},
+		bson.M{"$set": set, "$unset": bson.M{"lease_owner": "", "lease_until": ""}})
+	return err
+}
+
+// CancelTaskJobs удаляет ожидающие задачи указанного типа для задач taskIDs
+func (j *JobRepository) CancelTaskJobs(jobType string, taskIDs []primitive.ObjectID, ctx context.Context) (*mongo.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	result, err := j.db.DeleteMany(ctx, bson.M{"type": jobType, "task_id": bson.M{"$in": taskIDs}, "status": models.JobPending})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,361 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrLabelExists   = fmt.Errorf("label already exists")
+	ErrLabelNotFound = fmt.Errorf("label not found")
+)
+
+func NewLabelRepository(db, tasks *mongo.Collection) *LabelRepository {
+	return &LabelRepository{db: db, tasks: tasks}
+}
+
+type LabelRepository struct {
+	db    *mongo.Collection
+	tasks *mongo.Collection
+}
+
+// EnsureIndexes создаёт уникальный индекс имени метки в рабочем пространстве:
+// проверка через findByName не спасает от параллельного создания или переименования
+func (l *LabelRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	_, err := l.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "workspace_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (l *LabelRepository) CreateLabel(label *models.Label, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	existing, err := l.findByName(label.WorkspaceID, label.Name, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrLabelExists
+	}
+	label.CreatedAt = time.Now()
+	result, err := l.db.InsertOne(ctx, label)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrLabelExists
+	}
+	return result, err
+}
+
+func (l *LabelRepository) GetLabels(workspaceID primitive.ObjectID, ctx context.Context) ([]models.Label, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	labels := []models.Label{}
+	cursor, err := l.db.Find(ctx, bson.M{"workspace_id": workspaceID}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (l *LabelRepository) GetLabel(workspaceID, labelID primitive.ObjectID, ctx context.Context) (*models.Label, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var label models.Label
+	err := l.db.FindOne(ctx, bson.M{"_id": labelID, "workspace_id": workspaceID}).Decode(&label)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrLabelNotFound
+		}
+		return nil, err
+	}
+	return &label, nil
+}
+
+func (l *LabelRepository) findByName(workspaceID primitive.ObjectID, name string, ctx context.Context) (*models.Label, error) {
+	var label models.Label
+	err := l.db.FindOne(ctx, bson.M{"workspace_id": workspaceID, "name": name}).Decode(&label)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &label, nil
+}
+
+// RenameLabel переименовывает метку и тег во всех задачах рабочего пространства в одной транзакции.
+// Возвращает также задачи вне корзины, у которых сменился тег.
+func (l *LabelRepository) RenameLabel(workspaceID, labelID primitive.ObjectID, name, color string, ctx context.Context) (*models.Label, []primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	label, err := l.GetLabel(workspaceID, labelID, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if name != label.Name {
+		existing, err := l.findByName(workspaceID, name, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if existing != nil {
+			return nil, nil, ErrLabelExists
+		}
+	}
+	if color == "" {
+		color = label.Color
+	}
+
+	var changed []primitive.ObjectID
+	updateLabel := func(sc mongo.SessionContext) error {
+		_, err := l.db.UpdateOne(sc, bson.M{"_id": labelID, "workspace_id": workspaceID}, bson.M{"$set": bson.M{"name": name, "color": color}})
+		return err
+	}
+	if name == label.Name {
+		err = withTransaction(ctx, l.db.Database(), updateLabel)
+	} else {
+		changed, err = l.trackTags(ctx, bson.M{"workspace_id": workspaceID, "tags": label.Name}, func(sc mongo.SessionContext) error {
+			if err := updateLabel(sc); err != nil {
+				return err
+			}
+			return l.replaceTags(sc, workspaceID, []string{label.Name}, name)
+		})
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, nil, ErrLabelExists
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	label.Name = name
+	label.Color = color
+	return label, changed, nil
+}
+
+// MergeLabels переносит задачи с меток sourceIDs на метку targetID и удаляет исходные метки.
+// Возвращает также состояние до слияния, по которому его можно отменить, и изменённые задачи вне корзины.
+func (l *LabelRepository) MergeLabels(workspaceID, targetID primitive.ObjectID, sourceIDs []primitive.ObjectID, ctx context.Context) (*models.Label, *models.LabelUndo, []primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	target, err := l.GetLabel(workspaceID, targetID, ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var sources []models.Label
+	cursor, err := l.db.Find(ctx, bson.M{"_id": bson.M{"$in": sourceIDs, "$ne": targetID}, "workspace_id": workspaceID})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &sources); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(sources) == 0 {
+		return nil, nil, nil, ErrLabelNotFound
+	}
+
+	names := make([]string, 0, len(sources))
+	ids := make([]primitive.ObjectID, 0, len(sources))
+	for _, source := range sources {
+		names = append(names, source.Name)
+		ids = append(ids, source.ID)
+	}
+
+	undo := &models.LabelUndo{Labels: sources, TargetTag: target.Name}
+	changed, err := l.trackTags(ctx, bson.M{"workspace_id": workspaceID, "tags": bson.M{"$in": names}}, func(sc mongo.SessionContext) error {
+		tagged, err := l.taggedTasks(sc, workspaceID, names)
+		if err != nil {
+			return err
+		}
+		retagged, err := distinctIDs(sc, l.tasks, bson.M{"workspace_id": workspaceID, "tags": bson.M{"$in": names, "$ne": target.Name}})
+		if err != nil {
+			return err
+		}
+		undo.Tagged, undo.Retagged = tagged, retagged
+		if err := l.replaceTags(sc, workspaceID, names, target.Name); err != nil {
+			return err
+		}
+		_, err = l.db.DeleteMany(sc, bson.M{"_id": bson.M{"$in": ids}, "workspace_id": workspaceID})
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return target, undo, changed, nil
+}
+
+// DeleteLabel удаляет метку и убирает её тег из всех задач рабочего пространства.
+// Возвращает состояние до удаления, по которому его можно отменить, и изменённые задачи вне корзины.
+func (l *LabelRepository) DeleteLabel(workspaceID, labelID primitive.ObjectID, ctx context.Context) (*models.LabelUndo, []primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	label, err := l.GetLabel(workspaceID, labelID, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	undo := &models.LabelUndo{Labels: []models.Label{*label}}
+	filter := bson.M{"workspace_id": workspaceID, "tags": label.Name}
+	changed, err := l.trackTags(ctx, filter, func(sc mongo.SessionContext) error {
+		var err error
+		if undo.Tagged, err = l.taggedTasks(sc, workspaceID, []string{label.Name}); err != nil {
+			return err
+		}
+		if _, err := l.tasks.UpdateMany(sc, filter, bson.M{"$pull": bson.M{"tags": label.Name}}); err != nil {
+			return err
+		}
+		_, err = l.db.DeleteOne(sc, bson.M{"_id": labelID, "workspace_id": workspaceID})
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return undo, changed, nil
+}
+
+// UndoLabels восстанавливает метки и теги задач по состоянию, сохранённому при слиянии или удалении,
+// и возвращает изменённые задачи вне корзины.
+// Если метку с тем же именем успели создать заново, возвращается ErrLabelExists.
+func (l *LabelRepository) UndoLabels(workspaceID primitive.ObjectID, undo *models.LabelUndo, ctx context.Context) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	taskIDs := append([]primitive.ObjectID{}, undo.Retagged...)
+	for _, ids := range undo.Tagged {
+		taskIDs = append(taskIDs, ids...)
+	}
+	filter := bson.M{"_id": bson.M{"$in": taskIDs}, "workspace_id": workspaceID}
+	changed, err := l.trackTags(ctx, filter, func(sc mongo.SessionContext) error {
+		for _, label := range undo.Labels {
+			existing, err := l.findByName(workspaceID, label.Name, sc)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return ErrLabelExists
+			}
+			if _, err := l.db.InsertOne(sc, label); err != nil {
+				return err
+			}
+			if taskIDs := undo.Tagged[label.Name]; len(taskIDs) > 0 {
+				filter := bson.M{"_id": bson.M{"$in": taskIDs}, "workspace_id": workspaceID}
+				if _, err := l.tasks.UpdateMany(sc, filter, bson.M{"$addToSet": bson.M{"tags": label.Name}}); err != nil {
+					return err
+				}
+			}
+		}
+		if undo.TargetTag != "" && len(undo.Retagged) > 0 {
+			filter := bson.M{"_id": bson.M{"$in": undo.Retagged}, "workspace_id": workspaceID}
+			if _, err := l.tasks.UpdateMany(sc, filter, bson.M{"$pull": bson.M{"tags": undo.TargetTag}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrLabelExists
+	}
+	return changed, err
+}
+
+// trackTags выполняет fn в транзакции TaskRepository.track, чтобы смена тегов попала в историю и версии задач.
+// Возвращает задачи вне корзины, подходившие под filter до изменения: по ним рассылаются события.
+func (l *LabelRepository) trackTags(ctx context.Context, filter bson.M, fn func(sc mongo.SessionContext) error) ([]primitive.ObjectID, error) {
+	var changed []primitive.ObjectID
+	err := NewTaskRepository(l.tasks).track(ctx, filter, func(sc mongo.SessionContext) error {
+		var err error
+		if changed, err = distinctIDs(sc, l.tasks, notDeleted(bson.M{"$and": bson.A{filter}})); err != nil {
+			return err
+		}
+		return fn(sc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// taggedTasks возвращает для каждого тега из names задачи, у которых он есть
+func (l *LabelRepository) taggedTasks(ctx context.Context, workspaceID primitive.ObjectID, names []string) (map[string][]primitive.ObjectID, error) {
+	tagged := make(map[string][]primitive.ObjectID, len(names))
+	for _, name := range names {
+		ids, err := distinctIDs(ctx, l.tasks, bson.M{"workspace_id": workspaceID, "tags": name})
+		if err != nil {
+			return nil, err
+		}
+		tagged[name] = ids
+	}
+	return tagged, nil
+}
+
+// replaceTags заменяет теги from на тег to, не создавая дубликатов
+func (l *LabelRepository) replaceTags(sc mongo.SessionContext, workspaceID primitive.ObjectID, from []string, to string) error {
+	filter := bson.M{"workspace_id": workspaceID, "tags": bson.M{"$in": from}}
+	if _, err := l.tasks.UpdateMany(sc, filter, bson.M{"$addToSet": bson.M{"tags": to}}); err != nil {
+		return err
+	}
+	_, err := l.tasks.UpdateMany(sc, filter, bson.M{"$pull": bson.M{"tags": bson.M{"$in": from}}})
+	return err
+}
+
+// TagUsage возвращает теги рабочего пространства с префиксом prefix, отсортированные по частоте использования
+func (l *LabelRepository) TagUsage(workspaceID primitive.ObjectID, prefix string, limit int, ctx context.Context) ([]models.TagUsage, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix), Options: "i"}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: notDeleted(bson.M{"workspace_id": workspaceID})}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$match", Value: bson.M{"tags": pattern}}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := l.tasks.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	usage := []models.TagUsage{}
+	if err = cursor.All(ctx, &usage); err != nil {
+		return nil, err
+	}
+
+	labels, err := l.GetLabels(workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	colors := make(map[string]string, len(labels))
+	for _, label := range labels {
+		colors[label.Name] = label.Color
+	}
+	seen := make(map[string]bool, len(usage))
+	for i := range usage {
+		usage[i].Color = colors[usage[i].Name]
+		seen[usage[i].Name] = true
+	}
+	// Метки, которые ещё не использовались, идут в конце списка
+	re := regexp.MustCompile("(?i)^" + regexp.QuoteMeta(prefix))
+	for _, label := range labels {
+		if len(usage) >= limit {
+			break
+		}
+		if !seen[label.Name] && re.MatchString(label.Name) {
+			usage = append(usage, models.TagUsage{Name: label.Name, Color: label.Color})
+		}
+	}
+	return usage, nil
+}
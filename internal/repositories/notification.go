@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewNotificationRepository(db *mongo.Collection) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+type NotificationRepository struct {
+	db *mongo.Collection
+}
+
+func (n *NotificationRepository) CreateNotification(notification *models.Notification, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	notification.CreatedAt = time.Now()
+	result, err := n.db.InsertOne(ctx, notification)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetNotifications возвращает страницу уведомлений пользователя, новые первыми, и их общее количество
+func (n *NotificationRepository) GetNotifications(userID primitive.ObjectID, unreadOnly bool, page, limit int, ctx context.Context) ([]models.Notification, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+	total, err := n.db.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	notifications := []models.Notification{}
+	cursor, err := n.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+func (n *NotificationRepository) UnreadCount(userID primitive.ObjectID, ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	return n.db.CountDocuments(ctx, bson.M{"user_id": userID, "read": false})
+}
+
+func (n *NotificationRepository) MarkRead(userID, notificationID primitive.ObjectID, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	result, err := n.db.UpdateOne(ctx, bson.M{"_id": notificationID, "user_id": userID},
+		bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("notification not found")
+	}
+	return nil
+}
+
+func (n *NotificationRepository) MarkAllRead(userID primitive.ObjectID, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	result, err := n.db.UpdateMany(ctx, bson.M{"user_id": userID, "read": false},
+		bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
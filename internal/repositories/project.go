@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewProjectRepository(db, tasks *mongo.Collection) *ProjectRepository {
+	return &ProjectRepository{db: db, tasks: tasks}
+}
+
+type ProjectRepository struct {
+	db    *mongo.Collection
+	tasks *mongo.Collection
+}
+
+func (p *ProjectRepository) CreateProject(project *models.Project, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = project.CreatedAt
+	result, err := p.db.InsertOne(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (p *ProjectRepository) GetProjects(workspaceID primitive.ObjectID, includeArchived bool, ctx context.Context) ([]models.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	filter := bson.M{"workspace_id": workspaceID}
+	if !includeArchived {
+		filter["archived"] = false
+	}
+	projects := []models.Project{}
+	cursor, err := p.db.Find(ctx, filter, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (p *ProjectRepository) GetProject(workspaceID, projectID primitive.ObjectID, ctx context.Context) (*models.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var project models.Project
+	err := p.db.FindOne(ctx, bson.M{"_id": projectID, "workspace_id": workspaceID}).Decode(&project)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("project not found")
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (p *ProjectRepository) UpdateProject(project *models.Project, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	update := bson.M{
+		"name":        project.Name,
+		"description": project.Description,
+		"updated_at":  time.Now(),
+	}
+	result, err := p.db.UpdateOne(ctx, bson.M{"_id": project.ID, "workspace_id": project.WorkspaceID}, bson.M{"$set": update})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (p *ProjectRepository) SetArchived(workspaceID, projectID primitive.ObjectID, archived bool, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	update := bson.M{"archived": archived, "updated_at": time.Now()}
+	result, err := p.db.UpdateOne(ctx, bson.M{"_id": projectID, "workspace_id": workspaceID}, bson.M{"$set": update})
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("project not found")
+	}
+	return result, nil
+}
+
+// ArchivedProjectIDs возвращает идентификаторы архивных проектов рабочего пространства
+func (p *ProjectRepository) ArchivedProjectIDs(workspaceID primitive.ObjectID, ctx context.Context) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	ids, err := p.db.Distinct(ctx, "_id", bson.M{"workspace_id": workspaceID, "archived": true})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			result = append(result, oid)
+		}
+	}
+	return result, nil
+}
+
+// StatusCounts считает задачи по статусам для каждого проекта рабочего пространства.
+// Если projectID задан, считается только этот проект.
+func (p *ProjectRepository) StatusCounts(workspaceID primitive.ObjectID, projectID *primitive.ObjectID, ctx context.Context) ([]models.ProjectStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	match := notDeleted(bson.M{"workspace_id": workspaceID, "project_id": bson.M{"$ne": nil}})
+	if projectID != nil {
+		match["project_id"] = *projectID
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"project_id": "$project_id", "status": "$status"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := p.tasks.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID struct {
+			ProjectID primitive.ObjectID `bson:"project_id"`
+			Status    string             `bson:"status"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	stats := []models.ProjectStats{}
+	index := make(map[primitive.ObjectID]int)
+	for _, row := range rows {
+		i, ok := index[row.ID.ProjectID]
+		if !ok {
+			i = len(stats)
+			index[row.ID.ProjectID] = i
+			stats = append(stats, models.ProjectStats{ProjectID: row.ID.ProjectID, StatusCounts: map[string]int{}})
+		}
+		stats[i].StatusCounts[row.ID.Status] += row.Count
+		stats[i].Total += row.Count
+	}
+	return stats, nil
+}
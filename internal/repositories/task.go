@@ -5,15 +5,27 @@ import (
 	"fmt"
 	"task_manager/internal/config"
 	"task_manager/internal/models"
+	"task_manager/internal/utils"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var cfg = config.LoadConfig()
 
+var (
+	ErrTaskCycle     = fmt.Errorf("task cannot be moved under itself or its subtask")
+	ErrMaxTaskDepth  = fmt.Errorf("maximum task depth exceeded")
+	ErrTaskSelf      = fmt.Errorf("task cannot depend on itself")
+	ErrDependency    = fmt.Errorf("dependency would create a cycle")
+	ErrConflict      = fmt.Errorf("task was modified concurrently")
+	ErrNotInTrash    = fmt.Errorf("task not found in trash")
+	ErrParentDeleted = fmt.Errorf("parent task is in trash")
+)
+
 func NewTaskRepository(db *mongo.Collection) *TaskRepository {
 	return &TaskRepository{db: db}
 }
@@ -22,22 +34,90 @@ type TaskRepository struct {
 	db *mongo.Collection
 }
 
+// TaskFilter — дополнительные условия выборки задач
+type TaskFilter struct {
+	Tags              []string
+	MatchAllTags      bool
+	ProjectID         *primitive.ObjectID
+	ExcludeProjectIDs []primitive.ObjectID
+	Status            string
+	Priority          string
+}
+
+func (f TaskFilter) apply(filter bson.M) bson.M {
+	if len(f.Tags) > 0 {
+		if f.MatchAllTags {
+			filter["tags"] = bson.M{"$all": f.Tags}
+		} else {
+			filter["tags"] = bson.M{"$in": f.Tags}
+		}
+	}
+	if f.ProjectID != nil {
+		filter["project_id"] = *f.ProjectID
+	} else if len(f.ExcludeProjectIDs) > 0 {
+		filter["project_id"] = bson.M{"$nin": f.ExcludeProjectIDs}
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.Priority != "" {
+		filter["priority"] = f.Priority
+	}
+	return filter
+}
+
+// notDeleted добавляет к фильтру условие, исключающее задачи в корзине
+func notDeleted(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$exists": false}
+	return filter
+}
+
 func (t *TaskRepository) CreateTask(task *models.Task, ctx context.Context) (*mongo.InsertOneResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
 	defer cancel()
 	task.CreatedAt = time.Now()
-	result, err := t.db.InsertOne(ctx, task)
+	if task.ID.IsZero() {
+		task.ID = primitive.NewObjectID()
+	}
+	var result *mongo.InsertOneResult
+	err := t.track(ctx, bson.M{"_id": task.ID}, func(sc mongo.SessionContext) error {
+		var err error
+		result, err = t.db.InsertOne(sc, task)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (t *TaskRepository) GetTasks(user *models.User, ctx context.Context) ([]models.Task, error) {
+// CreateTasks создаёт несколько задач одной транзакцией: либо все, либо ни одной.
+// Идентификаторы можно назначить заранее, чтобы задачи ссылались друг на друга.
+func (t *TaskRepository) CreateTasks(tasks []*models.Task, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	now := time.Now()
+	docs := make([]interface{}, len(tasks))
+	ids := make([]primitive.ObjectID, len(tasks))
+	for i, task := range tasks {
+		task.CreatedAt = now
+		if task.ID.IsZero() {
+			task.ID = primitive.NewObjectID()
+		}
+		docs[i] = task
+		ids[i] = task.ID
+	}
+	return t.track(ctx, bson.M{"_id": bson.M{"$in": ids}}, func(sc mongo.SessionContext) error {
+		_, err := t.db.InsertMany(sc, docs)
+		return err
+	})
+}
+
+func (t *TaskRepository) GetTasks(workspaceID primitive.ObjectID, filter TaskFilter, ctx context.Context) ([]models.Task, error) {
 	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
 	defer cancel()
 	var tasks []models.Task
-	cursor, err := t.db.Find(ctx, bson.M{"user_id": user.ID})
+	cursor, err := t.db.Find(ctx, notDeleted(filter.apply(bson.M{"workspace_id": workspaceID})))
 	if err != nil {
 		return nil, err
 	}
@@ -45,39 +125,714 @@ func (t *TaskRepository) GetTasks(user *models.User, ctx context.Context) ([]mod
 	if err = cursor.All(ctx, &tasks); err != nil {
 		return nil, err
 	}
+	for i := range tasks {
+		tasks[i].CalcChecklistProgress()
+	}
 	return tasks, nil
 }
 
-func (t *TaskRepository) GetTask(taskID primitive.ObjectID, user *models.User, ctx context.Context) (*models.Task, error) {
+func (t *TaskRepository) GetTask(taskID, workspaceID primitive.ObjectID, ctx context.Context) (*models.Task, error) {
+	return t.findTask(notDeleted(bson.M{"_id": taskID, "workspace_id": workspaceID}), ctx)
+}
+
+// FindTask возвращает задачу без проверки рабочего пространства; только для фоновых задач
+func (t *TaskRepository) FindTask(taskID primitive.ObjectID, ctx context.Context) (*models.Task, error) {
+	return t.findTask(notDeleted(bson.M{"_id": taskID}), ctx)
+}
+
+func (t *TaskRepository) findTask(filter bson.M, ctx context.Context) (*models.Task, error) {
 	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
 	defer cancel()
 	var task models.Task
-	err := t.db.FindOne(ctx, bson.M{"_id": taskID, "user_id": user.ID}).Decode(&task)
+	err := t.db.FindOne(ctx, filter).Decode(&task)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("task not found")
 		}
 		return nil, err
 	}
+	task.CalcChecklistProgress()
 	return &task, nil
 }
 
 func (t *TaskRepository) UpdateTask(task *models.Task, ctx context.Context) (*mongo.UpdateResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
 	defer cancel()
-	result, err := t.db.UpdateOne(ctx, bson.M{"_id": task.ID, "user_id": task.UserID}, bson.M{"$set": task})
+	task.UpdatedAt = time.Now()
+	update := bson.M{
+		"title":            task.Title,
+		"description":      task.Description,
+		"status":           task.Status,
+		"priority":         task.Priority,
+		"due_date":         task.DueDate,
+		"tags":             task.Tags,
+		"project_id":       task.ProjectID,
+		"estimate":         task.Estimate,
+		"recurrence":       task.Recurrence,
+		"recurrence_start": task.RecurrenceStart,
+		"occurrence":       task.Occurrence,
+		"updated_at":       task.UpdatedAt,
+	}
+	result, err := t.trackedUpdate(ctx, notDeleted(bson.M{"_id": task.ID, "workspace_id": task.WorkspaceID}), bson.M{"$set": update})
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (t *TaskRepository) DeleteTask(userID, taskID primitive.ObjectID, ctx context.Context) (*mongo.DeleteResult, error) {
+// DeleteTask переносит задачу вместе с подзадачами в корзину и возвращает их идентификаторы
+func (t *TaskRepository) DeleteTask(workspaceID, taskID primitive.ObjectID, ctx context.Context) ([]primitive.ObjectID, error) {
 	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
 	defer cancel()
-	result, err := t.db.DeleteOne(ctx, bson.M{"_id": taskID, "user_id": userID})
+	var deleted []primitive.ObjectID
+	filter := notDeleted(bson.M{"workspace_id": workspaceID, "$or": bson.A{bson.M{"_id": taskID}, bson.M{"ancestors": taskID}}})
+	err := t.track(ctx, filter, func(sc mongo.SessionContext) error {
+		ids, err := distinctIDs(sc, t.db, filter)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return fmt.Errorf("task not found")
+		}
+		_, err = t.db.UpdateMany(sc, filter, bson.M{"$set": bson.M{"deleted_at": time.Now(), "deleted_with": taskID}})
+		deleted = ids
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// GetTrash возвращает задачи рабочего пространства, удалённые в корзину; подзадачи,
+// удалённые вместе с родителем, отдельно не возвращаются
+func (t *TaskRepository) GetTrash(workspaceID primitive.ObjectID, ctx context.Context) ([]models.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	filter := bson.M{
+		"workspace_id": workspaceID,
+		"deleted_at":   bson.M{"$exists": true},
+		"$expr":        bson.M{"$eq": bson.A{"$deleted_with", "$_id"}},
+	}
+	tasks := []models.Task{}
+	cursor, err := t.db.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		tasks[i].CalcChecklistProgress()
+	}
+	return tasks, nil
+}
+
+// RestoreTask возвращает из корзины задачу и подзадачи, удалённые вместе с ней.
+// Если родитель задачи сам в корзине, возвращается ErrParentDeleted.
+func (t *TaskRepository) RestoreTask(workspaceID, taskID primitive.ObjectID, ctx context.Context) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var restored []primitive.ObjectID
+	filter := bson.M{"workspace_id": workspaceID, "deleted_with": taskID}
+	err := t.track(ctx, filter, func(sc mongo.SessionContext) error {
+		var root models.Task
+		err := t.db.FindOne(sc, bson.M{"_id": taskID, "workspace_id": workspaceID, "deleted_with": taskID}).Decode(&root)
+		if err == mongo.ErrNoDocuments {
+			return ErrNotInTrash
+		}
+		if err != nil {
+			return err
+		}
+		if root.ParentID != nil {
+			if _, err := t.GetTask(*root.ParentID, workspaceID, sc); err != nil {
+				return ErrParentDeleted
+			}
+		}
+		if restored, err = distinctIDs(sc, t.db, filter); err != nil {
+			return err
+		}
+		_, err = t.db.UpdateMany(sc, filter, bson.M{"$unset": bson.M{"deleted_at": "", "deleted_with": ""}})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// PurgeTask окончательно удаляет задачу из корзины вместе с подзадачами
+func (t *TaskRepository) PurgeTask(workspaceID, taskID primitive.ObjectID, ctx context.Context) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	count, err := t.db.CountDocuments(ctx, bson.M{"_id": taskID, "workspace_id": workspaceID, "deleted_at": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrNotInTrash
+	}
+	return t.purge(ctx, bson.M{"workspace_id": workspaceID, "$or": bson.A{bson.M{"_id": taskID}, bson.M{"ancestors": taskID}}})
+}
+
+// PurgeDeletedBefore окончательно удаляет задачи всех пользователей, лежащие в корзине с момента до cutoff
+func (t *TaskRepository) PurgeDeletedBefore(cutoff time.Time, ctx context.Context) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	return t.purge(ctx, bson.M{"deleted_at": bson.M{"$lt": cutoff}})
+}
+
+// purge удаляет задачи и ссылки на них из зависимостей других задач
+func (t *TaskRepository) purge(ctx context.Context, filter bson.M) ([]primitive.ObjectID, error) {
+	var purged []primitive.ObjectID
+	err := t.track(ctx, filter, func(sc mongo.SessionContext) error {
+		ids, err := distinctIDs(sc, t.db, filter)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		if _, err = t.db.DeleteMany(sc, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+			return err
+		}
+		_, err = t.db.UpdateMany(sc, bson.M{"blocked_by": bson.M{"$in": ids}}, bson.M{"$pull": bson.M{"blocked_by": bson.M{"$in": ids}}})
+		purged = ids
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+// distinctIDs возвращает идентификаторы документов коллекции, подходящих под filter
+func distinctIDs(ctx context.Context, collection *mongo.Collection, filter bson.M) ([]primitive.ObjectID, error) {
+	values, err := collection.Distinct(ctx, "_id", filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// getDescendants возвращает все подзадачи; includeDeleted добавляет подзадачи из корзины,
+// чтобы при переносе дерева их предки оставались согласованными
+func (t *TaskRepository) getDescendants(workspaceID, taskID primitive.ObjectID, includeDeleted bool, ctx context.Context) ([]models.Task, error) {
+	var tasks []models.Task
+	filter := bson.M{"workspace_id": workspaceID, "ancestors": taskID}
+	if !includeDeleted {
+		filter = notDeleted(filter)
+	}
+	cursor, err := t.db.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetTaskTree возвращает задачу со всеми вложенными подзадачами
+func (t *TaskRepository) GetTaskTree(taskID, workspaceID primitive.ObjectID, ctx context.Context) (*models.TaskNode, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	root, err := t.GetTask(taskID, workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	descendants, err := t.getDescendants(workspaceID, taskID, false, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[primitive.ObjectID][]models.Task)
+	for _, task := range descendants {
+		if task.ParentID != nil {
+			children[*task.ParentID] = append(children[*task.ParentID], task)
+		}
+	}
+	var build func(task models.Task) models.TaskNode
+	build = func(task models.Task) models.TaskNode {
+		node := models.TaskNode{Task: task, Children: []models.TaskNode{}}
+		for _, child := range children[task.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+	tree := build(*root)
+	return &tree, nil
+}
+
+// PrepareSubtask заполняет у задачи родителя и предков, проверяя максимальную глубину
+func (t *TaskRepository) PrepareSubtask(task *models.Task, parentID, workspaceID primitive.ObjectID, ctx context.Context) error {
+	parent, err := t.GetTask(parentID, workspaceID, ctx)
+	if err != nil {
+		return err
+	}
+	if len(parent.Ancestors)+1 > cfg.MaxTaskDepth {
+		return ErrMaxTaskDepth
+	}
+	task.ParentID = &parent.ID
+	task.Ancestors = append(append([]primitive.ObjectID{}, parent.Ancestors...), parent.ID)
+	if task.ProjectID == nil {
+		task.ProjectID = parent.ProjectID
+	}
+	return nil
+}
+
+// MoveTask переносит задачу вместе с поддеревом под нового родителя (nil — в корень)
+func (t *TaskRepository) MoveTask(taskID primitive.ObjectID, parentID *primitive.ObjectID, workspaceID primitive.ObjectID, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	return t.track(ctx, bson.M{"_id": taskID, "workspace_id": workspaceID}, func(sc mongo.SessionContext) error {
+		task, err := t.GetTask(taskID, workspaceID, sc)
+		if err != nil {
+			return err
+		}
+
+		base := []primitive.ObjectID{}
+		if parentID != nil {
+			if *parentID == taskID {
+				return ErrTaskCycle
+			}
+			parent, err := t.GetTask(*parentID, workspaceID, sc)
+			if err != nil {
+				return err
+			}
+			for _, ancestor := range parent.Ancestors {
+				if ancestor == taskID {
+					return ErrTaskCycle
+				}
+			}
+			base = append(append(base, parent.Ancestors...), parent.ID)
+		}
+
+		descendants, err := t.getDescendants(workspaceID, taskID, true, sc)
+		if err != nil {
+			return err
+		}
+		height := 0
+		for _, d := range descendants {
+			if h := len(d.Ancestors) - len(task.Ancestors); h > height {
+				height = h
+			}
+		}
+		if len(base)+height > cfg.MaxTaskDepth {
+			return ErrMaxTaskDepth
+		}
+
+		writes := []mongo.WriteModel{
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": taskID, "workspace_id": workspaceID}).
+				SetUpdate(bson.M{"$set": bson.M{"parent_id": parentID, "ancestors": base, "updated_at": time.Now()}}),
+		}
+		prefix := append(append([]primitive.ObjectID{}, base...), taskID)
+		for _, d := range descendants {
+			ancestors := append(append([]primitive.ObjectID{}, prefix...), d.Ancestors[len(task.Ancestors)+1:]...)
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": d.ID, "workspace_id": workspaceID}).
+				SetUpdate(bson.M{"$set": bson.M{"ancestors": ancestors}}))
+		}
+		_, err = t.db.BulkWrite(sc, writes)
+		return err
+	})
+}
+
+// CompleteParents помечает родителя выполненным, если все его подзадачи выполнены, и так вверх по дереву
+func (t *TaskRepository) CompleteParents(taskID, workspaceID primitive.ObjectID, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	task, err := t.GetTask(taskID, workspaceID, ctx)
+	if err != nil {
+		return err
+	}
+	for task.Status == "completed" && task.ParentID != nil {
+		open, err := t.db.CountDocuments(ctx, notDeleted(bson.M{"workspace_id": workspaceID, "parent_id": *task.ParentID, "status": bson.M{"$ne": "completed"}}))
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return nil
+		}
+		if cfg.BlockCompletion {
+			blockers, err := t.OpenBlockers(*task.ParentID, workspaceID, ctx)
+			if err != nil {
+				return err
+			}
+			if len(blockers) > 0 {
+				return nil
+			}
+		}
+		_, err = t.trackedUpdate(ctx, bson.M{"_id": *task.ParentID, "workspace_id": workspaceID}, bson.M{"$set": bson.M{"status": "completed", "updated_at": time.Now()}})
+		if err != nil {
+			return err
+		}
+		if task, err = t.GetTask(*task.ParentID, workspaceID, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dependencyGraph возвращает граф зависимостей всех задач рабочего пространства
+func (t *TaskRepository) dependencyGraph(workspaceID primitive.ObjectID, ctx context.Context) (map[primitive.ObjectID][]primitive.ObjectID, error) {
+	cursor, err := t.db.Find(ctx, notDeleted(bson.M{"workspace_id": workspaceID, "blocked_by.0": bson.M{"$exists": true}}),
+		options.Find().SetProjection(bson.M{"_id": 1, "blocked_by": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var tasks []models.Task
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	deps := make(map[primitive.ObjectID][]primitive.ObjectID, len(tasks))
+	for _, task := range tasks {
+		deps[task.ID] = task.BlockedBy
+	}
+	return deps, nil
+}
+
+// AddDependency отмечает, что задача taskID заблокирована задачей blockerID
+func (t *TaskRepository) AddDependency(taskID, blockerID, workspaceID primitive.ObjectID, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	if taskID == blockerID {
+		return ErrTaskSelf
+	}
+	return t.track(ctx, bson.M{"_id": taskID, "workspace_id": workspaceID}, func(sc mongo.SessionContext) error {
+		if _, err := t.GetTask(taskID, workspaceID, sc); err != nil {
+			return err
+		}
+		if _, err := t.GetTask(blockerID, workspaceID, sc); err != nil {
+			return err
+		}
+		deps, err := t.dependencyGraph(workspaceID, sc)
+		if err != nil {
+			return err
+		}
+		if utils.DependsOn(deps, blockerID, taskID) {
+			return ErrDependency
+		}
+		_, err = t.db.UpdateOne(sc, bson.M{"_id": taskID, "workspace_id": workspaceID},
+			bson.M{"$addToSet": bson.M{"blocked_by": blockerID}, "$set": bson.M{"updated_at": time.Now()}})
+		return err
+	})
+}
+
+func (t *TaskRepository) RemoveDependency(taskID, blockerID, workspaceID primitive.ObjectID, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	result, err := t.trackedUpdate(ctx, notDeleted(bson.M{"_id": taskID, "workspace_id": workspaceID}),
+		bson.M{"$pull": bson.M{"blocked_by": blockerID}, "$set": bson.M{"updated_at": time.Now()}})
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
+
+// OpenBlockers возвращает незавершённые задачи, блокирующие taskID
+func (t *TaskRepository) OpenBlockers(taskID, workspaceID primitive.ObjectID, ctx context.Context) ([]models.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	task, err := t.GetTask(taskID, workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	blockers := []models.Task{}
+	if len(task.BlockedBy) == 0 {
+		return blockers, nil
+	}
+	cursor, err := t.db.Find(ctx, notDeleted(bson.M{"_id": bson.M{"$in": task.BlockedBy}, "workspace_id": workspaceID, "status": bson.M{"$ne": "completed"}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &blockers); err != nil {
+		return nil, err
+	}
+	return blockers, nil
+}
+
+// Schedule строит топологический порядок задач и критический путь по оценкам (в минутах).
+// Если taskIDs пуст, берутся все незавершённые задачи рабочего пространства.
+func (t *TaskRepository) Schedule(taskIDs []primitive.ObjectID, workspaceID primitive.ObjectID, ctx context.Context) (*models.TaskSchedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	filter := notDeleted(bson.M{"workspace_id": workspaceID})
+	if len(taskIDs) > 0 {
+		filter["_id"] = bson.M{"$in": taskIDs}
+	} else {
+		filter["status"] = bson.M{"$ne": "completed"}
+	}
+	cursor, err := t.db.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var tasks []models.Task
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]primitive.ObjectID, 0, len(tasks))
+	deps := make(map[primitive.ObjectID][]primitive.ObjectID, len(tasks))
+	durations := make(map[primitive.ObjectID]int, len(tasks))
+	for _, task := range tasks {
+		nodes = append(nodes, task.ID)
+		deps[task.ID] = task.BlockedBy
+		if task.Status != "completed" {
+			durations[task.ID] = task.Estimate
+		}
+	}
+	order, err := utils.TopologicalSort(nodes, deps)
+	if err != nil {
+		return nil, err
+	}
+	path, total := utils.CriticalPath(order, deps, durations)
+	if path == nil {
+		path = []primitive.ObjectID{}
+	}
+	return &models.TaskSchedule{Order: order, CriticalPath: path, TotalEstimate: total}, nil
+}
+
+func (t *TaskRepository) updateChecklist(filter, update bson.M, ctx context.Context) (*models.Task, error) {
+	filter = notDeleted(filter)
+	var task models.Task
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := t.track(ctx, filter, func(sc mongo.SessionContext) error {
+		return t.db.FindOneAndUpdate(sc, filter, update, opts).Decode(&task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	task.CalcChecklistProgress()
+	return &task, nil
+}
+
+// AddChecklistItem вставляет пункт в позицию position (nil — в конец списка)
+func (t *TaskRepository) AddChecklistItem(taskID primitive.ObjectID, item models.ChecklistItem, position *int, workspaceID primitive.ObjectID, ctx context.Context) (*models.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	item.ID = primitive.NewObjectID()
+	push := bson.M{"$each": bson.A{item}}
+	if position != nil {
+		push["$position"] = *position
+	}
+	task, err := t.updateChecklist(bson.M{"_id": taskID, "workspace_id": workspaceID},
+		bson.M{"$push": bson.M{"checklist": push}, "$set": bson.M{"updated_at": time.Now()}}, ctx)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("task not found")
+	}
+	return task, err
+}
+
+// ToggleChecklistItem инвертирует отметку пункта. Обновление условное:
+// если пункт изменился между чтением и записью, возвращается ErrConflict.
+func (t *TaskRepository) ToggleChecklistItem(taskID, itemID, workspaceID primitive.ObjectID, ctx context.Context) (*models.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	current, err := t.GetTask(taskID, workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var item *models.ChecklistItem
+	for i := range current.Checklist {
+		if current.Checklist[i].ID == itemID {
+			item = &current.Checklist[i]
+		}
+	}
+	if item == nil {
+		return nil, fmt.Errorf("checklist item not found")
+	}
+
+	filter := bson.M{"_id": taskID, "workspace_id": workspaceID, "checklist": bson.M{"$elemMatch": bson.M{"id": itemID, "done": item.Done}}}
+	task, err := t.updateChecklist(filter, bson.M{"$set": bson.M{"checklist.$.done": !item.Done, "updated_at": time.Now()}}, ctx)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrConflict
+	}
+	return task, err
+}
+
+// MoveChecklistItem переставляет пункт в позицию position.
+// Чеклист заменяется целиком только если он не менялся с момента чтения.
+func (t *TaskRepository) MoveChecklistItem(taskID, itemID primitive.ObjectID, position int, workspaceID primitive.ObjectID, ctx context.Context) (*models.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	current, err := t.GetTask(taskID, workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	from := -1
+	for i, item := range current.Checklist {
+		if item.ID == itemID {
+			from = i
+		}
+	}
+	if from < 0 {
+		return nil, fmt.Errorf("checklist item not found")
+	}
+	if position < 0 {
+		position = 0
+	}
+	if position >= len(current.Checklist) {
+		position = len(current.Checklist) - 1
+	}
+
+	reordered := make([]models.ChecklistItem, 0, len(current.Checklist))
+	reordered = append(reordered, current.Checklist[:from]...)
+	reordered = append(reordered, current.Checklist[from+1:]...)
+	reordered = append(reordered[:position], append([]models.ChecklistItem{current.Checklist[from]}, reordered[position:]...)...)
+
+	filter := bson.M{"_id": taskID, "workspace_id": workspaceID, "checklist": current.Checklist}
+	task, err := t.updateChecklist(filter, bson.M{"$set": bson.M{"checklist": reordered, "updated_at": time.Now()}}, ctx)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrConflict
+	}
+	return task, err
+}
+
+func (t *TaskRepository) DeleteChecklistItem(taskID, itemID, workspaceID primitive.ObjectID, ctx context.Context) (*models.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	filter := bson.M{"_id": taskID, "workspace_id": workspaceID, "checklist.id": itemID}
+	task, err := t.updateChecklist(filter, bson.M{"$pull": bson.M{"checklist": bson.M{"id": itemID}}, "$set": bson.M{"updated_at": time.Now()}}, ctx)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("checklist item not found")
+	}
+	return task, err
+}
+
+// CreateNextOccurrence создаёт следующее повторение задачи со сдвинутым сроком
+func (t *TaskRepository) CreateNextOccurrence(task *models.Task, dueDate time.Time, ctx context.Context) (*models.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	next := *task
+	next.ID = primitive.NewObjectID()
+	next.Status = "pending"
+	next.DueDate = &dueDate
+	next.Occurrence = task.Occurrence + 1
+	next.BlockedBy = nil
+	next.ExternalID = ""
+	next.CreatedAt = time.Now()
+	next.UpdatedAt = time.Time{}
+	next.Checklist = make([]models.ChecklistItem, len(task.Checklist))
+	for i, item := range task.Checklist {
+		next.Checklist[i] = models.ChecklistItem{ID: primitive.NewObjectID(), Text: item.Text}
+	}
+	created := false
+	err := t.track(ctx, bson.M{"_id": bson.M{"$in": []primitive.ObjectID{task.ID, next.ID}}}, func(sc mongo.SessionContext) error {
+		// Правило переходит к новой задаче: повторное завершение этой задачи (после возврата в работу
+		// или отмены) не создаст ещё одну копию того же повторения
+		result, err := t.db.UpdateOne(sc, bson.M{"_id": task.ID, "recurrence": task.Recurrence},
+			bson.M{"$unset": bson.M{"recurrence": ""}, "$set": bson.M{"updated_at": next.CreatedAt}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			// Следующее повторение уже создал параллельный запрос
+			return nil
+		}
+		if _, err := t.db.InsertOne(sc, &next); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if err != nil || !created {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// AssignTask назначает исполнителя задачи; nil снимает назначение
+func (t *TaskRepository) AssignTask(taskID primitive.ObjectID, assigneeID *primitive.ObjectID, workspaceID primitive.ObjectID, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	update := bson.M{"$set": bson.M{"assignee_id": assigneeID, "updated_at": time.Now()}}
+	if assigneeID == nil {
+		update = bson.M{"$unset": bson.M{"assignee_id": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+	return t.trackedUpdate(ctx, notDeleted(bson.M{"_id": taskID, "workspace_id": workspaceID}), update)
+}
+
+// SetWatching подписывает пользователя на изменения задачи или отписывает его
+func (t *TaskRepository) SetWatching(taskID, userID, workspaceID primitive.ObjectID, watch bool, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	update := bson.M{"$addToSet": bson.M{"watchers": userID}}
+	if !watch {
+		update = bson.M{"$pull": bson.M{"watchers": userID}}
+	}
+	return t.trackedUpdate(ctx, notDeleted(bson.M{"_id": taskID, "workspace_id": workspaceID}), update)
+}
+
+// GetAssignedTasks возвращает задачи пользователя из всех его рабочих пространств
+func (t *TaskRepository) GetAssignedTasks(userID primitive.ObjectID, workspaceIDs []primitive.ObjectID, filter TaskFilter, ctx context.Context) ([]models.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var tasks []models.Task
+	query := notDeleted(filter.apply(bson.M{"assignee_id": userID, "workspace_id": bson.M{"$in": workspaceIDs}}))
+	opts := options.Find().SetSort(bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := t.db.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		tasks[i].CalcChecklistProgress()
+	}
+	return tasks, nil
+}
+
+// GetCalendarTasks возвращает задачи со сроком, за которые отвечает пользователь:
+// назначенные ему и созданные им без исполнителя
+func (t *TaskRepository) GetCalendarTasks(userID primitive.ObjectID, workspaceIDs []primitive.ObjectID, ctx context.Context) ([]models.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var tasks []models.Task
+	query := notDeleted(bson.M{
+		"workspace_id": bson.M{"$in": workspaceIDs},
+		"due_date":     bson.M{"$ne": nil},
+		"$or": bson.A{
+			bson.M{"assignee_id": userID},
+			bson.M{"user_id": userID, "assignee_id": nil},
+		},
+	})
+	opts := options.Find().SetSort(bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := t.db.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetTasksDueBetween возвращает незавершённые задачи всех пользователей со сроком в интервале (from, to]
+func (t *TaskRepository) GetTasksDueBetween(from, to time.Time, ctx context.Context) ([]models.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var tasks []models.Task
+	filter := notDeleted(bson.M{"due_date": bson.M{"$gt": from, "$lte": to}, "status": bson.M{"$ne": "completed"}})
+	cursor, err := t.db.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
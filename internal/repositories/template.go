@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TemplateRepository struct {
+	db *mongo.Collection
+}
+
+func NewTemplateRepository(db *mongo.Collection) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+func (t *TemplateRepository) CreateTemplate(template *models.TaskTemplate, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+	result, err := t.db.InsertOne(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (t *TemplateRepository) GetTemplates(workspaceID primitive.ObjectID, ctx context.Context) ([]models.TaskTemplate, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	templates := []models.TaskTemplate{}
+	cursor, err := t.db.Find(ctx, bson.M{"workspace_id": workspaceID}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (t *TemplateRepository) GetTemplate(workspaceID, templateID primitive.ObjectID, ctx context.Context) (*models.TaskTemplate, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var template models.TaskTemplate
+	err := t.db.FindOne(ctx, bson.M{"_id": templateID, "workspace_id": workspaceID}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("template not found")
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (t *TemplateRepository) UpdateTemplate(template *models.TaskTemplate, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	update := bson.M{
+		"name":        template.Name,
+		"description": template.Description,
+		"tasks":       template.Tasks,
+		"updated_at":  time.Now(),
+	}
+	result, err := t.db.UpdateOne(ctx, bson.M{"_id": template.ID, "workspace_id": template.WorkspaceID}, bson.M{"$set": update})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (t *TemplateRepository) DeleteTemplate(workspaceID, templateID primitive.ObjectID, ctx context.Context) (*mongo.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	result, err := t.db.DeleteOne(ctx, bson.M{"_id": templateID, "workspace_id": workspaceID})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
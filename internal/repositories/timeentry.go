@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrTimerRunning   = fmt.Errorf("timer is already running")
+	ErrNoRunningTimer = fmt.Errorf("no running timer")
+	ErrTimeOverlap    = fmt.Errorf("time entry overlaps another entry")
+)
+
+// Документ на пользователя, который обновляется в транзакциях ручных записей: параллельная транзакция
+// того же пользователя получает write conflict, повторяется и уже видит записанное время
+const timeEntryLockCollectionName = "time_entry_locks"
+
+func NewTimeEntryRepository(db, tasks *mongo.Collection) *TimeEntryRepository {
+	return &TimeEntryRepository{db: db, tasks: tasks}
+}
+
+type TimeEntryRepository struct {
+	db    *mongo.Collection
+	tasks *mongo.Collection
+}
+
+// TimesheetFilter — условия выборки табеля: записи, начатые в [From, To)
+type TimesheetFilter struct {
+	From      time.Time
+	To        time.Time
+	UserID    *primitive.ObjectID
+	ProjectID *primitive.ObjectID
+}
+
+// EnsureIndexes создаёт индексы; уникальный частичный индекс не даёт запустить второй таймер
+func (t *TimeEntryRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	_, err := t.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.M{"user_id": 1},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"running": true}),
+		},
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "started_at", Value: 1}}},
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "started_at", Value: 1}}},
+	})
+	return err
+}
+
+// StartTimer запускает таймер; у пользователя может идти только один таймер во всех пространствах
+func (t *TimeEntryRepository) StartTimer(entry *models.TimeEntry, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	now := time.Now()
+	entry.StartedAt = now
+	entry.EndedAt = nil
+	entry.Duration = 0
+	entry.Running = true
+	entry.Manual = false
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+	result, err := t.db.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrTimerRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StopTimer останавливает таймер пользователя, в каком бы пространстве он ни шёл, и возвращает получившуюся запись
+func (t *TimeEntryRepository) StopTimer(userID primitive.ObjectID, ctx context.Context) (*models.TimeEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	now := time.Now()
+	// Длительность считается на сервере базы одной операцией, чтобы параллельный stop не записал её дважды
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"ended_at":   now,
+			"duration":   bson.M{"$toLong": bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{now, "$started_at"}}, 1000}}},
+			"updated_at": now,
+		}}},
+		{{Key: "$unset", Value: "running"}},
+	}
+	var entry models.TimeEntry
+	err := t.db.FindOneAndUpdate(ctx, bson.M{"user_id": userID, "running": true}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNoRunningTimer
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetRunningTimer возвращает запущенный таймер пользователя в любом пространстве
+func (t *TimeEntryRepository) GetRunningTimer(userID primitive.ObjectID, ctx context.Context) (*models.TimeEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var entry models.TimeEntry
+	err := t.db.FindOne(ctx, bson.M{"user_id": userID, "running": true}).Decode(&entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNoRunningTimer
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// CreateEntry добавляет запись, внесённую вручную
+func (t *TimeEntryRepository) CreateEntry(entry *models.TimeEntry, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	entry.Running = false
+	entry.Manual = true
+	entry.Duration = int64(entry.EndedAt.Sub(entry.StartedAt) / time.Second)
+	entry.CreatedAt = time.Now()
+	entry.UpdatedAt = entry.CreatedAt
+	var result *mongo.InsertOneResult
+	err := withTransaction(ctx, t.db.Database(), func(sc mongo.SessionContext) error {
+		if err := t.lockUser(sc, entry.UserID); err != nil {
+			return err
+		}
+		if err := t.checkOverlap(entry, sc); err != nil {
+			return err
+		}
+		var err error
+		result, err = t.db.InsertOne(sc, entry)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateEntry меняет интервал и заметку завершённой записи; запущенный таймер так не изменить
+func (t *TimeEntryRepository) UpdateEntry(entry *models.TimeEntry, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	update := bson.M{
+		"started_at": entry.StartedAt,
+		"ended_at":   entry.EndedAt,
+		"duration":   int64(entry.EndedAt.Sub(entry.StartedAt) / time.Second),
+		"note":       entry.Note,
+		"manual":     true,
+		"updated_at": time.Now(),
+	}
+	filter := bson.M{"_id": entry.ID, "workspace_id": entry.WorkspaceID, "user_id": entry.UserID, "running": bson.M{"$ne": true}}
+	var result *mongo.UpdateResult
+	err := withTransaction(ctx, t.db.Database(), func(sc mongo.SessionContext) error {
+		if err := t.lockUser(sc, entry.UserID); err != nil {
+			return err
+		}
+		if err := t.checkOverlap(entry, sc); err != nil {
+			return err
+		}
+		var err error
+		result, err = t.db.UpdateOne(sc, filter, bson.M{"$set": update})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (t *TimeEntryRepository) DeleteEntry(workspaceID, userID, entryID primitive.ObjectID, ctx context.Context) (*mongo.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	result, err := t.db.DeleteOne(ctx, bson.M{"_id": entryID, "workspace_id": workspaceID, "user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// lockUser обновляет документ-блокировку пользователя в транзакции sc
+func (t *TimeEntryRepository) lockUser(sc mongo.SessionContext, userID primitive.ObjectID) error {
+	_, err := t.db.Database().Collection(timeEntryLockCollectionName).UpdateOne(sc, bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"locked_at": time.Now()}}, options.Update().SetUpsert(true))
+	return err
+}
+
+// checkOverlap не даёт пользователю записать одно и то же время дважды, в том числе поверх идущего таймера
+func (t *TimeEntryRepository) checkOverlap(entry *models.TimeEntry, ctx context.Context) error {
+	filter := bson.M{
+		"_id":        bson.M{"$ne": entry.ID},
+		"user_id":    entry.UserID,
+		"started_at": bson.M{"$lt": *entry.EndedAt},
+		"$or": bson.A{
+			bson.M{"ended_at": bson.M{"$gt": entry.StartedAt}},
+			bson.M{"running": true},
+		},
+	}
+	count, err := t.db.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTimeOverlap
+	}
+	return nil
+}
+
+func (t *TimeEntryRepository) GetTaskEntries(workspaceID, taskID primitive.ObjectID, ctx context.Context) ([]models.TimeEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	entries := []models.TimeEntry{}
+	cursor, err := t.db.Find(ctx, bson.M{"workspace_id": workspaceID, "task_id": taskID}, options.Find().SetSort(bson.M{"started_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetTimesheet возвращает завершённые записи пространства вместе с названием и проектом задачи.
+// Задачи в корзине тоже учитываются: потраченное на них время уже отработано.
+func (t *TimeEntryRepository) GetTimesheet(workspaceID primitive.ObjectID, filter TimesheetFilter, ctx context.Context) ([]models.TimesheetEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	match := bson.M{
+		"workspace_id": workspaceID,
+		"running":      bson.M{"$ne": true},
+		"started_at":   bson.M{"$gte": filter.From, "$lt": filter.To},
+	}
+	if filter.UserID != nil {
+		match["user_id"] = *filter.UserID
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.D{{Key: "started_at", Value: 1}}}},
+		{{Key: "$lookup", Value: bson.M{"from": t.tasks.Name(), "localField": "task_id", "foreignField": "_id", "as": "task"}}},
+		{{Key: "$set", Value: bson.M{
+			"task_title": bson.M{"$arrayElemAt": bson.A{"$task.title", 0}},
+			"project_id": bson.M{"$arrayElemAt": bson.A{"$task.project_id", 0}},
+		}}},
+		{{Key: "$project", Value: bson.M{"task": 0}}},
+	}
+	if filter.ProjectID != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"project_id": *filter.ProjectID}}})
+	}
+	cursor, err := t.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	entries := []models.TimesheetEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrUndoExpired = fmt.Errorf("undo token is invalid or expired")
+
+func NewUndoRepository(db *mongo.Collection) *UndoRepository {
+	return &UndoRepository{db: db}
+}
+
+type UndoRepository struct {
+	db *mongo.Collection
+}
+
+func (u *UndoRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := u.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// Просроченные записи удаляет сама Mongo
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (u *UndoRepository) CreateUndo(action *models.UndoAction, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	action.CreatedAt = time.Now()
+	result, err := u.db.InsertOne(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+	action.ID = result.InsertedID.(primitive.ObjectID)
+	return result, nil
+}
+
+// ClaimUndo атомарно помечает отмену использованной, чтобы её нельзя было выполнить дважды.
+// Отменить операцию может только тот, кто её выполнил.
+func (u *UndoRepository) ClaimUndo(tokenHash string, userID primitive.ObjectID, ctx context.Context) (*models.UndoAction, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	now := time.Now()
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"user_id":    userID,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	var action models.UndoAction
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := u.db.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"used_at": now}}, opts).Decode(&action)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrUndoExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// ReleaseUndo снимает отметку использования, если обратная операция не удалась
+func (u *UndoRepository) ReleaseUndo(actionID primitive.ObjectID, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	_, err := u.db.UpdateOne(ctx, bson.M{"_id": actionID}, bson.M{"$unset": bson.M{"used_at": ""}})
+	return err
+}
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository struct {
@@ -20,6 +22,17 @@ func NewUserRepository(db *mongo.Collection) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+func (u *UserRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	_, err := u.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"calendar_token_hash": 1},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"calendar_token_hash": bson.M{"$type": "string"}}),
+	})
+	return err
+}
+
 func (u *UserRepository) CreateUser(user *models.User, ctx context.Context) (*mongo.InsertOneResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
 	defer cancel()
@@ -70,3 +83,70 @@ func (u *UserRepository) FindUserByID(id string, ctx context.Context) (*models.U
 	}
 	return &user, nil
 }
+
+// FindUsersByUsernames возвращает пользователей с указанными именами
+func (u *UserRepository) FindUsersByUsernames(usernames []string, ctx context.Context) ([]models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	users := []models.User{}
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	cursor, err := u.db.Find(ctx, bson.M{"username": bson.M{"$in": usernames}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (u *UserRepository) GetUser(userID primitive.ObjectID, ctx context.Context) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var user models.User
+	err := u.db.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (u *UserRepository) UpdateNotificationPreferences(userID primitive.ObjectID, prefs map[string]models.NotificationPreference, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	set := bson.M{}
+	for notificationType, pref := range prefs {
+		set["notification_preferences."+notificationType] = pref
+	}
+	result, err := u.db.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": set})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindUserByCalendarToken ищет пользователя по хешу токена календарной подписки
+func (u *UserRepository) FindUserByCalendarToken(tokenHash string, ctx context.Context) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var user models.User
+	err := u.db.FindOne(ctx, bson.M{"calendar_token_hash": tokenHash}).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetCalendarToken заменяет токен календарной подписки; пустой хеш отключает подписку
+func (u *UserRepository) SetCalendarToken(userID primitive.ObjectID, tokenHash string, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	update := bson.M{"$set": bson.M{"calendar_token_hash": tokenHash}}
+	if tokenHash == "" {
+		update = bson.M{"$unset": bson.M{"calendar_token_hash": ""}}
+	}
+	return u.db.UpdateOne(ctx, bson.M{"_id": userID}, update)
+}
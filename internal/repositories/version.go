@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VersionCollectionName — коллекция снимков задач; версии пишет TaskRepository
+// в той же транзакции, что и запись истории
+const VersionCollectionName = "task_versions"
+
+func NewVersionRepository(db *mongo.Collection) *VersionRepository {
+	return &VersionRepository{db: db}
+}
+
+type VersionRepository struct {
+	db *mongo.Collection
+}
+
+func (v *VersionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := v.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "task_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// GetVersions возвращает страницу версий задачи, новые первыми
+func (v *VersionRepository) GetVersions(taskID, workspaceID primitive.ObjectID, page, limit int, ctx context.Context) ([]models.TaskVersion, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	filter := bson.M{"task_id": taskID, "workspace_id": workspaceID}
+	total, err := v.db.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	versions := []models.TaskVersion{}
+	cursor, err := v.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &versions); err != nil {
+		return nil, 0, err
+	}
+	return versions, total, nil
+}
+
+func (v *VersionRepository) GetVersion(taskID, workspaceID primitive.ObjectID, version int, ctx context.Context) (*models.TaskVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var result models.TaskVersion
+	err := v.db.FindOne(ctx, bson.M{"task_id": taskID, "workspace_id": workspaceID, "version": version}).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("version not found")
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DiffTasks сравнивает два снимка задачи по полям
+func DiffTasks(old, next *models.Task) ([]models.FieldChange, error) {
+	oldDoc, err := toDocument(old)
+	if err != nil {
+		return nil, err
+	}
+	nextDoc, err := toDocument(next)
+	if err != nil {
+		return nil, err
+	}
+	return diffDocuments(oldDoc, nextDoc), nil
+}
+
+func toDocument(v interface{}) (bson.M, error) {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	err = bson.Unmarshal(data, &doc)
+	return doc, err
+}
+
+// saveVersions сохраняет снимки задач после изменения и удаляет версии сверх cfg.TaskVersionLimit
+func (t *TaskRepository) saveVersions(sc mongo.SessionContext, activities []models.Activity, after map[primitive.ObjectID]bson.M) error {
+	versions := t.db.Database().Collection(VersionCollectionName)
+	for _, activity := range activities {
+		doc, ok := after[activity.TaskID]
+		if !ok {
+			// Задача удалена окончательно — её версии больше не нужны
+			if _, err := versions.DeleteMany(sc, bson.M{"task_id": activity.TaskID}); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		var task models.Task
+		if err := bson.Unmarshal(data, &task); err != nil {
+			return err
+		}
+
+		var latest models.TaskVersion
+		opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"version": 1})
+		err = versions.FindOne(sc, bson.M{"task_id": activity.TaskID}, opts).Decode(&latest)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+		number := latest.Version + 1
+		_, err = versions.InsertOne(sc, models.TaskVersion{
+			TaskID:      activity.TaskID,
+			WorkspaceID: activity.WorkspaceID,
+			Version:     number,
+			Action:      activity.Action,
+			ActorID:     activity.ActorID,
+			Task:        task,
+			CreatedAt:   activity.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if cfg.TaskVersionLimit > 0 && number > cfg.TaskVersionLimit {
+			stale := bson.M{"task_id": activity.TaskID, "version": bson.M{"$lte": number - cfg.TaskVersionLimit}}
+			if _, err := versions.DeleteMany(sc, stale); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RestoreVersion возвращает содержимое задачи к снимку snapshot. Положение в дереве,
+// зависимости и повторение не восстанавливаются: правило повторения уже могло перейти
+// к следующему вхождению, и вернувшее его старое вхождение создало бы дубль при завершении.
+// Восстановление само становится новой версией.
+func (t *TaskRepository) RestoreVersion(snapshot *models.Task, workspaceID primitive.ObjectID, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	update := bson.M{
+		"title":       snapshot.Title,
+		"description": snapshot.Description,
+		"status":      snapshot.Status,
+		"priority":    snapshot.Priority,
+		"due_date":    snapshot.DueDate,
+		"tags":        snapshot.Tags,
+		"project_id":  snapshot.ProjectID,
+		"assignee_id": snapshot.AssigneeID,
+		"watchers":    snapshot.Watchers,
+		"estimate":    snapshot.Estimate,
+		"checklist":   snapshot.Checklist,
+		"updated_at":  time.Now(),
+	}
+	return t.trackedUpdate(ctx, notDeleted(bson.M{"_id": snapshot.ID, "workspace_id": workspaceID}), bson.M{"$set": update})
+}
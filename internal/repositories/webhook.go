@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewWebhookRepository(webhooks, deliveries *mongo.Collection) *WebhookRepository {
+	return &WebhookRepository{db: webhooks, deliveries: deliveries}
+}
+
+type WebhookRepository struct {
+	db         *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+// EnsureIndexes создаёт уникальный индекс по ключу доставки, чтобы одно событие
+// не доставлялось дважды, если его получили несколько экземпляров приложения
+func (w *WebhookRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	_, err := w.deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"key": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func (w *WebhookRepository) CreateWebhook(webhook *models.Webhook, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	webhook.CreatedAt = time.Now()
+	webhook.UpdatedAt = webhook.CreatedAt
+	result, err := w.db.InsertOne(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	webhook.ID = result.InsertedID.(primitive.ObjectID)
+	return result, nil
+}
+
+func (w *WebhookRepository) GetWebhooks(workspaceID primitive.ObjectID, ctx context.Context) ([]models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	webhooks := []models.Webhook{}
+	cursor, err := w.db.Find(ctx, bson.M{"workspace_id": workspaceID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (w *WebhookRepository) GetWebhook(webhookID primitive.ObjectID, ctx context.Context) (*models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var webhook models.Webhook
+	if err := w.db.FindOne(ctx, bson.M{"_id": webhookID}).Decode(&webhook); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (w *WebhookRepository) GetWorkspaceWebhook(workspaceID, webhookID primitive.ObjectID, ctx context.Context) (*models.Webhook, error) {
+	webhook, err := w.GetWebhook(webhookID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.WorkspaceID != workspaceID {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	return webhook, nil
+}
+
+// ActiveWebhooks возвращает включённые webhooks рабочего пространства, подписанные на eventType
+func (w *WebhookRepository) ActiveWebhooks(workspaceID primitive.ObjectID, eventType string, ctx context.Context) ([]models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	webhooks := []models.Webhook{}
+	cursor, err := w.db.Find(ctx, bson.M{"workspace_id": workspaceID, "active": true, "events": eventType})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook меняет адрес, подписки и состояние; при включении счётчик ошибок сбрасывается
+func (w *WebhookRepository) UpdateWebhook(webhook *models.Webhook, ctx context.Context) (*models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	set := bson.M{"url": webhook.URL, "events": webhook.Events, "active": webhook.Active, "updated_at": time.Now()}
+	if webhook.Active {
+		set["failures"] = 0
+	}
+	var updated models.Webhook
+	err := w.db.FindOneAndUpdate(ctx, bson.M{"_id": webhook.ID, "workspace_id": webhook.WorkspaceID}, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteWebhook удаляет webhook вместе с журналом доставок
+func (w *WebhookRepository) DeleteWebhook(workspaceID, webhookID primitive.ObjectID, ctx context.Context) (*mongo.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	result, err := w.db.DeleteOne(ctx, bson.M{"_id": webhookID, "workspace_id": workspaceID})
+	if err != nil {
+		return nil, err
+	}
+	if result.DeletedCount > 0 {
+		if _, err := w.deliveries.DeleteMany(ctx, bson.M{"webhook_id": webhookID}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// RecordSuccess сбрасывает счётчик подряд неудачных доставок
+func (w *WebhookRepository) RecordSuccess(webhookID primitive.ObjectID, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	_, err := w.db.UpdateOne(ctx, bson.M{"_id": webhookID}, bson.M{"$set": bson.M{"failures": 0}})
+	return err
+}
+
+// RecordFailure увеличивает счётчик неудачных доставок и отключает webhook,
+// когда счётчик достигает disableAfter. Возвращает true, если webhook был отключён.
+func (w *WebhookRepository) RecordFailure(webhookID primitive.ObjectID, disableAfter int, ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"failures": bson.M{"$add": bson.A{"$failures", 1}}}}},
+		{{Key: "$set", Value: bson.M{
+			"active":     bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$failures", disableAfter}}, false, "$active"}},
+			"updated_at": "$$NOW",
+		}}},
+	}
+	var before models.Webhook
+	err := w.db.FindOneAndUpdate(ctx, bson.M{"_id": webhookID}, update).Decode(&before)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+	return before.Active && before.Failures+1 >= disableAfter, nil
+}
+
+// CreateDelivery сохраняет доставку. Если доставка с таким ключом уже есть, возвращается существующая.
+func (w *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery, ctx context.Context) (*models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	delivery.ID = primitive.NewObjectID()
+	delivery.Status = models.DeliveryPending
+	delivery.CreatedAt = time.Now()
+	var stored models.WebhookDelivery
+	err := w.deliveries.FindOneAndUpdate(ctx, bson.M{"key": delivery.Key}, bson.M{"$setOnInsert": delivery},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&stored)
+	if mongo.IsDuplicateKeyError(err) {
+		// Параллельная вставка с тем же ключом
+		err = w.deliveries.FindOne(ctx, bson.M{"key": delivery.Key}).Decode(&stored)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (w *WebhookRepository) GetDeliveries(webhookID primitive.ObjectID, page, limit int, ctx context.Context) ([]models.WebhookDelivery, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	filter := bson.M{"webhook_id": webhookID}
+	total, err := w.deliveries.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	deliveries := []models.WebhookDelivery{}
+	cursor, err := w.deliveries.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (w *WebhookRepository) GetDelivery(deliveryID primitive.ObjectID, ctx context.Context) (*models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var delivery models.WebhookDelivery
+	if err := w.deliveries.FindOne(ctx, bson.M{"_id": deliveryID}).Decode(&delivery); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("delivery not found")
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// UpdateDelivery сохраняет результат очередной попытки доставки
+func (w *WebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	set := bson.M{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"response_status": delivery.ResponseStatus,
+		"response_body":   delivery.ResponseBody,
+		"error":           delivery.Error,
+	}
+	if delivery.DeliveredAt != nil {
+		set["delivered_at"] = delivery.DeliveredAt
+	}
+	_, err := w.deliveries.UpdateOne(ctx, bson.M{"_id": delivery.ID}, bson.M{"$set": set})
+	return err
+}
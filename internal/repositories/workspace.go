@@ -0,0 +1,247 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"task_manager/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrInviteInvalid = errors.New("invite is invalid or expired")
+	ErrAlreadyMember = errors.New("user is already a member of the workspace")
+)
+
+func NewWorkspaceRepository(db, invites *mongo.Collection) *WorkspaceRepository {
+	return &WorkspaceRepository{db: db, invites: invites}
+}
+
+type WorkspaceRepository struct {
+	db      *mongo.Collection
+	invites *mongo.Collection
+}
+
+// EnsureIndexes гарантирует одно личное пространство на пользователя и уникальность токенов приглашений
+func (w *WorkspaceRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	_, err := w.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.M{"owner_id": 1},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"personal": true}),
+		},
+		{Keys: bson.M{"members.user_id": 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.invites.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"token_hash": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (w *WorkspaceRepository) CreateWorkspace(workspace *models.Workspace, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	now := time.Now()
+	workspace.CreatedAt = now
+	workspace.UpdatedAt = now
+	workspace.Members = []models.WorkspaceMember{{UserID: workspace.OwnerID, Role: models.RoleOwner, JoinedAt: now}}
+	result, err := w.db.InsertOne(ctx, workspace)
+	if err != nil {
+		return nil, err
+	}
+	workspace.ID = result.InsertedID.(primitive.ObjectID)
+	return result, nil
+}
+
+// PersonalWorkspace возвращает личное пространство пользователя, создавая его при первом обращении
+func (w *WorkspaceRepository) PersonalWorkspace(userID primitive.ObjectID, ctx context.Context) (*models.Workspace, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	now := time.Now()
+	filter := bson.M{"owner_id": userID, "personal": true}
+	update := bson.M{"$setOnInsert": bson.M{
+		"name":       "Personal",
+		"members":    []models.WorkspaceMember{{UserID: userID, Role: models.RoleOwner, JoinedAt: now}},
+		"created_at": now,
+		"updated_at": now,
+	}}
+	var workspace models.Workspace
+	err := w.db.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&workspace)
+	if mongo.IsDuplicateKeyError(err) {
+		// Параллельный запрос уже создал пространство
+		err = w.db.FindOne(ctx, filter).Decode(&workspace)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// GetWorkspaces возвращает пространства, в которых состоит пользователь
+func (w *WorkspaceRepository) GetWorkspaces(userID primitive.ObjectID, ctx context.Context) ([]models.Workspace, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	workspaces := []models.Workspace{}
+	opts := options.Find().SetSort(bson.D{{Key: "personal", Value: -1}, {Key: "name", Value: 1}})
+	cursor, err := w.db.Find(ctx, bson.M{"members.user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &workspaces); err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+func (w *WorkspaceRepository) GetWorkspace(workspaceID primitive.ObjectID, ctx context.Context) (*models.Workspace, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var workspace models.Workspace
+	if err := w.db.FindOne(ctx, bson.M{"_id": workspaceID}).Decode(&workspace); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("workspace not found")
+		}
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func (w *WorkspaceRepository) RenameWorkspace(workspaceID primitive.ObjectID, name string, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	return w.db.UpdateOne(ctx, bson.M{"_id": workspaceID}, bson.M{"$set": bson.M{"name": name, "updated_at": time.Now()}})
+}
+
+// SetMemberRole меняет роль участника; роль владельца не меняется
+func (w *WorkspaceRepository) SetMemberRole(workspaceID, userID primitive.ObjectID, role string, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	filter := bson.M{"_id": workspaceID, "members": bson.M{"$elemMatch": bson.M{"user_id": userID, "role": bson.M{"$ne": models.RoleOwner}}}}
+	return w.db.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"members.$.role": role, "updated_at": time.Now()}})
+}
+
+// RemoveMember исключает участника; владельца исключить нельзя
+func (w *WorkspaceRepository) RemoveMember(workspaceID, userID primitive.ObjectID, ctx context.Context) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	filter := bson.M{"_id": workspaceID, "members": bson.M{"$elemMatch": bson.M{"user_id": userID, "role": bson.M{"$ne": models.RoleOwner}}}}
+	return w.db.UpdateOne(ctx, filter, bson.M{
+		"$pull": bson.M{"members": bson.M{"user_id": userID}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+}
+
+func (w *WorkspaceRepository) CreateInvite(invite *models.Invite, ctx context.Context) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	invite.CreatedAt = time.Now()
+	result, err := w.invites.InsertOne(ctx, invite)
+	if err != nil {
+		return nil, err
+	}
+	invite.ID = result.InsertedID.(primitive.ObjectID)
+	return result, nil
+}
+
+// GetInvites возвращает непринятые и неистёкшие приглашения пространства
+func (w *WorkspaceRepository) GetInvites(workspaceID primitive.ObjectID, ctx context.Context) ([]models.Invite, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	invites := []models.Invite{}
+	filter := bson.M{"workspace_id": workspaceID, "accepted_at": nil, "expires_at": bson.M{"$gt": time.Now()}}
+	cursor, err := w.invites.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &invites); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+func (w *WorkspaceRepository) DeleteInvite(workspaceID, inviteID primitive.ObjectID, ctx context.Context) (*mongo.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	return w.invites.DeleteOne(ctx, bson.M{"_id": inviteID, "workspace_id": workspaceID, "accepted_at": nil})
+}
+
+// AcceptInvite добавляет пользователя в пространство по токену приглашения.
+// Приглашение одноразовое и действует только для email, на который было отправлено.
+func (w *WorkspaceRepository) AcceptInvite(tokenHash string, user *models.User, ctx context.Context) (*models.Workspace, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ContextTimeout)
+	defer cancel()
+	var workspace *models.Workspace
+	err := withTransaction(ctx, w.db.Database(), func(sc mongo.SessionContext) error {
+		now := time.Now()
+		var invite models.Invite
+		err := w.invites.FindOneAndUpdate(sc,
+			bson.M{"token_hash": tokenHash, "email": strings.ToLower(user.Email), "accepted_at": nil, "expires_at": bson.M{"$gt": now}},
+			bson.M{"$set": bson.M{"accepted_at": now, "accepted_by": user.ID}},
+		).Decode(&invite)
+		if err == mongo.ErrNoDocuments {
+			return ErrInviteInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		member := models.WorkspaceMember{UserID: user.ID, Role: invite.Role, JoinedAt: now}
+		result, err := w.db.UpdateOne(sc,
+			bson.M{"_id": invite.WorkspaceID, "members.user_id": bson.M{"$ne": user.ID}},
+			bson.M{"$push": bson.M{"members": member}, "$set": bson.M{"updated_at": now}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrAlreadyMember
+		}
+		workspace, err = w.GetWorkspace(invite.WorkspaceID, sc)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// MigrateLegacyData переносит документы, созданные до появления рабочих пространств,
+// в личные пространства их владельцев. Повторный запуск ничего не меняет.
+func (w *WorkspaceRepository) MigrateLegacyData(ctx context.Context, collections ...*mongo.Collection) error {
+	legacy := bson.M{"workspace_id": bson.M{"$exists": false}}
+	for _, collection := range collections {
+		userIDs, err := collection.Distinct(ctx, "user_id", legacy)
+		if err != nil {
+			return err
+		}
+		for _, id := range userIDs {
+			userID, ok := id.(primitive.ObjectID)
+			if !ok {
+				continue
+			}
+			workspace, err := w.PersonalWorkspace(userID, ctx)
+			if err != nil {
+				return err
+			}
+			filter := bson.M{"user_id": userID, "workspace_id": bson.M{"$exists": false}}
+			if _, err := collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"workspace_id": workspace.ID}}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
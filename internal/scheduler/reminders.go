@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"task_manager/internal/models"
+	"task_manager/internal/notifier"
+	"task_manager/internal/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	reminderSweepInterval = time.Minute
+	reminderMaxAttempts   = 3
+)
+
+// Reminders ставит и отправляет напоминания о сроках задач и о просрочке.
+// Периодический обход находит задачи, для которых скоро наступит момент напоминания,
+// и ставит по ним отдельные задачи планировщика с уникальным ключом.
+type Reminders struct {
+	tasks    *mongo.Collection
+	notifier notifier.Notifier
+	offsets  []time.Duration
+}
+
+func RegisterReminders(s *Scheduler, tasks *mongo.Collection, n notifier.Notifier, offsets []time.Duration) {
+	r := &Reminders{tasks: tasks, notifier: n, offsets: offsets}
+	s.Every("reminder_sweep", reminderSweepInterval, func(ctx context.Context, job *models.Job) error {
+		return r.sweep(ctx, s)
+	})
+	s.Handle("reminder", r.fire)
+	s.Handle("overdue", r.fire)
+}
+
+func (r *Reminders) sweep(ctx context.Context, s *Scheduler) error {
+	var maxOffset time.Duration
+	for _, offset := range r.offsets {
+		if offset > maxOffset {
+			maxOffset = offset
+		}
+	}
+
+	now := time.Now()
+	horizon := 2 * reminderSweepInterval
+	tr := repositories.NewTaskRepository(r.tasks)
+	tasks, err := tr.GetTasksDueBetween(now.Add(-horizon), now.Add(maxOffset+horizon), ctx)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		for _, offset := range r.offsets {
+			key := fmt.Sprintf("reminder:%s:%d:%s", task.ID.Hex(), task.DueDate.Unix(), offset)
+			if err := r.schedule(ctx, s, "reminder", key, task, offset); err != nil {
+				return err
+			}
+		}
+		key := fmt.Sprintf("overdue:%s:%d", task.ID.Hex(), task.DueDate.Unix())
+		if err := r.schedule(ctx, s, "overdue", key, task, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schedule ставит уведомление на момент task.DueDate - offset
+func (r *Reminders) schedule(ctx context.Context, s *Scheduler, jobType, key string, task models.Task, offset time.Duration) error {
+	now := time.Now()
+	horizon := 2 * reminderSweepInterval
+	runAt := task.DueDate.Add(-offset)
+	// Ставятся только ближайшие напоминания; сильно опоздавшие пропускаются
+	if runAt.Before(now.Add(-horizon)) || runAt.After(now.Add(horizon)) {
+		return nil
+	}
+	userID, taskID := task.UserID, task.ID
+	return s.Schedule(ctx, &models.Job{
+		Type:        jobType,
+		Key:         key,
+		UserID:      &userID,
+		TaskID:      &taskID,
+		RunAt:       runAt,
+		MaxAttempts: reminderMaxAttempts,
+		Payload:     map[string]interface{}{"due_unix": task.DueDate.Unix(), "offset": offset.String()},
+	})
+}
+
+func (r *Reminders) fire(ctx context.Context, job *models.Job) error {
+	if job.TaskID == nil {
+		return nil
+	}
+	tr := repositories.NewTaskRepository(r.tasks)
+	task, err := tr.FindTask(*job.TaskID, ctx)
+	if err != nil {
+		// Задача удалена — напоминать не о чем
+		return nil
+	}
+	dueUnix, _ := job.Payload["due_unix"].(int64)
+	if task.Status == "completed" || task.DueDate == nil || task.DueDate.Unix() != dueUnix {
+		return nil
+	}
+
+	// Напоминание получает исполнитель, а если его нет — автор задачи
+	recipient := task.UserID
+	if task.AssigneeID != nil {
+		recipient = *task.AssigneeID
+	}
+	if job.Type == "overdue" {
+		return r.notifier.Notify(ctx, notifier.Message{
+			UserID: recipient,
+			TaskID: &task.ID,
+			Type:   models.NotificationTaskOverdue,
+			Title:  "Task overdue",
+			Body:   fmt.Sprintf("%q is overdue", task.Title),
+		})
+	}
+	offset, _ := job.Payload["offset"].(string)
+	return r.notifier.Notify(ctx, notifier.Message{
+		UserID: recipient,
+		TaskID: &task.ID,
+		Type:   models.NotificationTaskDueSoon,
+		Title:  "Task due soon",
+		Body:   fmt.Sprintf("%q is due in %s", task.Title, formatOffset(offset)),
+	})
+}
+
+// formatOffset сокращает запись длительности: "24h0m0s" -> "24h"
+func formatOffset(offset string) string {
+	if strings.HasSuffix(offset, "m0s") {
+		offset = strings.TrimSuffix(offset, "0s")
+	}
+	if strings.HasSuffix(offset, "h0m") {
+		offset = strings.TrimSuffix(offset, "0m")
+	}
+	return offset
+}
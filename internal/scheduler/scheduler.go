@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandlerFunc выполняет задачу планировщика; ошибка приводит к повтору по Backoff
+type HandlerFunc func(ctx context.Context, job *models.Job) error
+
+// Scheduler периодически забирает готовые задачи из Mongo и выполняет их.
+// Каждую задачу выполняет только один экземпляр приложения — тот, кто взял аренду.
+type Scheduler struct {
+	jobs     *repositories.JobRepository
+	handlers map[string]HandlerFunc
+	periodic []*models.Job
+	owner    string
+	interval time.Duration
+	lease    time.Duration
+}
+
+func New(jobs *repositories.JobRepository, interval, lease time.Duration) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		jobs:     jobs,
+		handlers: map[string]HandlerFunc{},
+		owner:    fmt.Sprintf("%s-%d-%s", host, os.Getpid(), primitive.NewObjectID().Hex()),
+		interval: interval,
+		lease:    lease,
+	}
+}
+
+// Handle регистрирует обработчик задач типа jobType
+func (s *Scheduler) Handle(jobType string, handler HandlerFunc) {
+	s.handlers[jobType] = handler
+}
+
+// Every регистрирует периодическую задачу, которая выполняется раз в interval
+func (s *Scheduler) Every(jobType string, interval time.Duration, handler HandlerFunc) {
+	s.Handle(jobType, handler)
+	s.periodic = append(s.periodic, &models.Job{
+		Type:     jobType,
+		Key:      "periodic:" + jobType,
+		Interval: interval,
+	})
+}
+
+// Schedule ставит задачу в очередь
+func (s *Scheduler) Schedule(ctx context.Context, job *models.Job) error {
+	return s.jobs.Schedule(job, ctx)
+}
+
+// Run обрабатывает очередь до отмены ctx
+func (s *Scheduler) Run(ctx context.Context) error {
+	if err := s.jobs.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	for _, job := range s.periodic {
+		job.RunAt = time.Now()
+		if err := s.jobs.Schedule(job, ctx); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain выполняет задачи, пока в очереди есть готовые
+func (s *Scheduler) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := s.jobs.Claim(s.owner, s.lease, ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to claim job")
+			return
+		}
+		if job == nil {
+			return
+		}
+		s.execute(ctx, job)
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, job *models.Job) {
+	handler, ok := s.handlers[job.Type]
+	var err error
+	if !ok {
+		err = fmt.Errorf("no handler for job type %q", job.Type)
+	} else {
+		jobCtx, cancel := context.WithTimeout(ctx, s.lease)
+		err = runSafely(jobCtx, handler, job)
+		cancel()
+	}
+
+	if err == nil {
+		if err := s.jobs.Complete(job, s.owner, ctx); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.Hex()).Msg("failed to complete job")
+		}
+		return
+	}
+
+	log.Error().Err(err).Str("job_id", job.ID.Hex()).Str("type", job.Type).Int("attempt", job.Attempts).Msg("job failed")
+	var retryAt *time.Time
+	switch {
+	case job.Interval > 0:
+		next := time.Now().Add(job.Interval)
+		retryAt = &next
+	case job.Attempts < job.MaxAttempts:
+		next := time.Now().Add(Backoff(job.Attempts))
+		retryAt = &next
+	}
+	if err := s.jobs.Fail(job, s.owner, err, retryAt, ctx); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID.Hex()).Msg("failed to record job failure")
+	}
+}
+
+func runSafely(ctx context.Context, handler HandlerFunc, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// Backoff возвращает экспоненциальную задержку перед повтором attempt-й попытки
+func Backoff(attempt int) time.Duration {
+	const (
+		base     = 30 * time.Second
+		maxDelay = 6 * time.Hour
+	)
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"task_manager/internal/config"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore хранит содержимое вложений по ключу
+type BlobStore interface {
+	// Put сохраняет содержимое r под ключом key и возвращает количество записанных байт
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open открывает length байт блоба начиная с offset; length < 0 — до конца
+	Open(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore создаёт хранилище, выбранное в конфигурации (local или gridfs)
+func NewBlobStore(cfg *config.Config, db *mongo.Database) (BlobStore, error) {
+	switch cfg.BlobStore {
+	case "local":
+		return NewLocalStore(cfg.BlobDir)
+	case "gridfs":
+		return NewGridFSStore(db)
+	default:
+		return nil, fmt.Errorf("unknown blob store: %s", cfg.BlobStore)
+	}
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func limit(rc io.ReadCloser, length int64) io.ReadCloser {
+	if length < 0 {
+		return rc
+	}
+	return limitedReadCloser{Reader: io.LimitReader(rc, length), Closer: rc}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSStore хранит блобы в GridFS; ключ блоба используется как идентификатор файла
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+func NewGridFSStore(db *mongo.Database) (*GridFSStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName("attachments"))
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+func (s *GridFSStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	stream, err := s.bucket.OpenUploadStreamWithID(key, key)
+	if err != nil {
+		return 0, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetWriteDeadline(deadline)
+	}
+	n, err := io.Copy(stream, r)
+	if err != nil {
+		stream.Abort()
+		return 0, err
+	}
+	if err := stream.Close(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *GridFSStore) Open(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	stream, err := s.bucket.OpenDownloadStream(key)
+	if err == gridfs.ErrFileNotFound {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		if _, err := stream.Skip(offset); err != nil {
+			stream.Close()
+			return nil, err
+		}
+	}
+	return limit(stream, length), nil
+}
+
+func (s *GridFSStore) Delete(ctx context.Context, key string) error {
+	err := s.bucket.DeleteContext(ctx, key)
+	if err == gridfs.ErrFileNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package utils
+
+import "errors"
+
+var ErrCycle = errors.New("dependency graph contains a cycle")
+
+// DependsOn проверяет, зависит ли a (напрямую или транзитивно) от b.
+// deps[n] — список узлов, которые блокируют n.
+func DependsOn[K comparable](deps map[K][]K, a, b K) bool {
+	visited := map[K]bool{}
+	stack := []K{a}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, blocker := range deps[n] {
+			if blocker == b {
+				return true
+			}
+			if !visited[blocker] {
+				visited[blocker] = true
+				stack = append(stack, blocker)
+			}
+		}
+	}
+	return false
+}
+
+// TopologicalSort упорядочивает узлы так, что блокирующие задачи идут раньше зависимых.
+// Учитываются только связи внутри nodes; при равенстве сохраняется исходный порядок.
+func TopologicalSort[K comparable](nodes []K, deps map[K][]K) ([]K, error) {
+	inSet := make(map[K]bool, len(nodes))
+	for _, n := range nodes {
+		inSet[n] = true
+	}
+	indegree := make(map[K]int, len(nodes))
+	dependents := make(map[K][]K, len(nodes))
+	for _, n := range nodes {
+		for _, blocker := range deps[n] {
+			if inSet[blocker] {
+				indegree[n]++
+				dependents[blocker] = append(dependents[blocker], n)
+			}
+		}
+	}
+
+	order := make([]K, 0, len(nodes))
+	var queue []K
+	for _, n := range nodes {
+		if indegree[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		order = append(order, n)
+		for _, d := range dependents[n] {
+			indegree[d]--
+			if indegree[d] == 0 {
+				queue = append(queue, d)
+			}
+		}
+	}
+	if len(order) != len(nodes) {
+		return nil, ErrCycle
+	}
+	return order, nil
+}
+
+// CriticalPath находит самую длинную по суммарной длительности цепочку зависимостей.
+// order должен быть результатом TopologicalSort.
+func CriticalPath[K comparable](order []K, deps map[K][]K, durations map[K]int) ([]K, int) {
+	finish := make(map[K]int, len(order))
+	prev := make(map[K]K, len(order))
+	hasPrev := make(map[K]bool, len(order))
+	seen := make(map[K]bool, len(order))
+
+	var end K
+	best := -1
+	for _, n := range order {
+		start := 0
+		for _, blocker := range deps[n] {
+			if seen[blocker] && finish[blocker] > start {
+				start = finish[blocker]
+				prev[n] = blocker
+				hasPrev[n] = true
+			}
+		}
+		finish[n] = start + durations[n]
+		seen[n] = true
+		if finish[n] > best {
+			best = finish[n]
+			end = n
+		}
+	}
+	if best < 0 {
+		return nil, 0
+	}
+
+	var path []K
+	for n := end; ; n = prev[n] {
+		path = append(path, n)
+		if !hasPrev[n] {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, best
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icalLineLimit — максимальная длина строки в октетах без учёта CRLF (RFC 5545, 3.1)
+const icalLineLimit = 75
+
+var icalTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// ICalendar собирает документ iCalendar (RFC 5545)
+type ICalendar struct {
+	b strings.Builder
+}
+
+// NewICalendar начинает календарь с именем name, которое показывают календарные приложения
+func NewICalendar(name string) *ICalendar {
+	c := &ICalendar{}
+	c.Property("BEGIN", "VCALENDAR")
+	c.Property("VERSION", "2.0")
+	c.Property("PRODID", "-//task_manager//Tasks//EN")
+	c.Property("CALSCALE", "GREGORIAN")
+	c.Text("X-WR-CALNAME", name)
+	return c
+}
+
+// Property добавляет свойство как есть; name может содержать параметры, например "DUE;VALUE=DATE"
+func (c *ICalendar) Property(name, value string) {
+	c.writeLine(name + ":" + value)
+}
+
+// Text добавляет текстовое свойство, экранируя спецсимволы
+func (c *ICalendar) Text(name, value string) {
+	c.Property(name, icalTextEscaper.Replace(value))
+}
+
+// List добавляет свойство со списком текстовых значений, например CATEGORIES
+func (c *ICalendar) List(name string, values []string) {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = icalTextEscaper.Replace(v)
+	}
+	c.Property(name, strings.Join(escaped, ","))
+}
+
+// Time добавляет свойство с моментом времени в UTC
+func (c *ICalendar) Time(name string, t time.Time) {
+	c.Property(name, t.UTC().Format("20060102T150405Z"))
+}
+
+// Date добавляет свойство с датой без времени
+func (c *ICalendar) Date(name string, t time.Time) {
+	c.Property(name+";VALUE=DATE", t.Format("20060102"))
+}
+
+// Bytes завершает календарь и возвращает его содержимое
+func (c *ICalendar) Bytes() []byte {
+	c.Property("END", "VCALENDAR")
+	return []byte(c.b.String())
+}
+
+// writeLine пишет строку, перенося её по 75 октетов без разрыва символов UTF-8
+func (c *ICalendar) writeLine(line string) {
+	limit := icalLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		c.b.WriteString(line[:cut])
+		c.b.WriteString("\r\n ")
+		line = line[cut:]
+		// Пробел в начале строки продолжения тоже занимает октет
+		limit = icalLineLimit - 1
+	}
+	c.b.WriteString(line)
+	c.b.WriteString("\r\n")
+}
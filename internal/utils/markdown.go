@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	mdCodeSpan = regexp.MustCompile("`([^`]+)`")
+	mdBold     = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	mdItalic   = regexp.MustCompile(`(^|[^\w*])[*_]([^*_]+)[*_]`)
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdHeading  = regexp.MustCompile(`^(#{1,3})\s+(.*)$`)
+	mdListItem = regexp.MustCompile(`^[-*]\s+(.*)$`)
+	mdMention  = regexp.MustCompile(`(^|[^\w@])@([A-Za-z0-9_.-]{3,})`)
+	mentionRe  = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.-]{3,})`)
+)
+
+// ExtractMentions возвращает уникальные имена пользователей, упомянутых через @
+func ExtractMentions(text string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range mentionRe.FindAllStringSubmatch(text, -1) {
+		name := strings.TrimRight(m[1], ".-")
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// RenderMarkdown преобразует ограниченное подмножество Markdown в HTML.
+// Весь исходный текст экранируется, поэтому пользовательский HTML в результат не попадает,
+// а ссылки допускаются только со схемами http, https и mailto.
+func RenderMarkdown(src string) string {
+	var out strings.Builder
+	var paragraph []string
+	inList, inCode := false, false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + strings.Join(paragraph, "<br>") + "</p>")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if inList {
+			out.WriteString("</ul>")
+			inList = false
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if inCode {
+				out.WriteString("</code></pre>")
+			} else {
+				flushParagraph()
+				closeList()
+				out.WriteString("<pre><code>")
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flushParagraph()
+			closeList()
+		case mdHeading.MatchString(trimmed):
+			flushParagraph()
+			closeList()
+			m := mdHeading.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(m[1])))
+			out.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">")
+		case mdListItem.MatchString(trimmed):
+			flushParagraph()
+			if !inList {
+				out.WriteString("<ul>")
+				inList = true
+			}
+			out.WriteString("<li>" + renderInline(mdListItem.FindStringSubmatch(trimmed)[1]) + "</li>")
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			closeList()
+			out.WriteString("<blockquote>" + renderInline(strings.TrimSpace(trimmed[1:])) + "</blockquote>")
+		default:
+			closeList()
+			paragraph = append(paragraph, renderInline(trimmed))
+		}
+	}
+	if inCode {
+		out.WriteString("</code></pre>")
+	}
+	flushParagraph()
+	closeList()
+	return out.String()
+}
+
+func renderInline(text string) string {
+	// Код и ссылки подставляются после остальных правил, чтобы их содержимое не менялось
+	var spans []string
+	hold := func(fragment string) string {
+		spans = append(spans, fragment)
+		return "\x00" + strconv.Itoa(len(spans)-1) + "\x00"
+	}
+
+	text = strings.ReplaceAll(text, "\x00", "")
+	text = mdCodeSpan.ReplaceAllStringFunc(text, func(s string) string {
+		return hold("<code>" + html.EscapeString(s[1:len(s)-1]) + "</code>")
+	})
+	text = mdLink.ReplaceAllStringFunc(text, func(s string) string {
+		m := mdLink.FindStringSubmatch(s)
+		lower := strings.ToLower(m[2])
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "mailto:") {
+			return m[1]
+		}
+		return hold(`<a href="` + html.EscapeString(m[2]) + `" rel="nofollow noopener" target="_blank">` + html.EscapeString(m[1]) + "</a>")
+	})
+
+	text = html.EscapeString(text)
+	text = mdBold.ReplaceAllString(text, "<strong>$1</strong>")
+	text = mdItalic.ReplaceAllString(text, "$1<em>$2</em>")
+	text = mdMention.ReplaceAllString(text, `$1<span class="mention">@$2</span>`)
+
+	for i, span := range spans {
+		text = strings.Replace(text, "\x00"+strconv.Itoa(i)+"\x00", span, 1)
+	}
+	return text
+}
@@ -0,0 +1,446 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QuickAdd — задача, разобранная из строки быстрого добавления вроде
+// "Deploy billing fix tomorrow 5pm !high #backend"
+type QuickAdd struct {
+	Title    string          `json:"title"`
+	DueDate  *time.Time      `json:"due_date"`
+	AllDay   bool            `json:"all_day"`
+	Priority string          `json:"priority,omitempty"`
+	Tags     []string        `json:"tags"`
+	Matches  []QuickAddMatch `json:"matches"`
+}
+
+// QuickAddMatch — распознанный фрагмент строки: date, time, priority или tag
+type QuickAddMatch struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+var (
+	quickAddISODate  = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	quickAddDotDate  = regexp.MustCompile(`^(\d{1,2})\.(\d{1,2})(?:\.(\d{2}|\d{4}))?$`)
+	quickAddDay      = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?$`)
+	quickAddYear     = regexp.MustCompile(`^\d{4}$`)
+	quickAddClock    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	quickAddTag      = regexp.MustCompile(`^#([\p{L}\d_/-]+)$`)
+	quickAddTrailing = ",.;?!"
+)
+
+var quickAddPriorities = map[string]string{
+	"!high": "high", "!h": "high", "!1": "high", "!!!": "high", "!высокий": "high", "!срочно": "high",
+	"!medium": "medium", "!m": "medium", "!2": "medium", "!!": "medium", "!средний": "medium",
+	"!low": "low", "!l": "low", "!3": "low", "!низкий": "low",
+}
+
+var quickAddWeekdays = map[string]time.Weekday{
+	"monday": time.Monday, "mon": time.Monday, "понедельник": time.Monday, "пн": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday, "вторник": time.Tuesday, "вт": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "среда": time.Wednesday, "среду": time.Wednesday, "ср": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday, "четверг": time.Thursday, "чт": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "пятница": time.Friday, "пятницу": time.Friday, "пт": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "суббота": time.Saturday, "субботу": time.Saturday, "сб": time.Saturday,
+	"sunday": time.Sunday, "sun": time.Sunday, "воскресенье": time.Sunday, "вс": time.Sunday,
+}
+
+// Сокращения дней недели легко спутать со словами названия ("Fix sat solver"),
+// поэтому без "next" они считаются датой только после предлога или в конце строки
+var quickAddWeekdayAbbrevs = map[string]bool{
+	"mon": true, "tue": true, "tues": true, "wed": true, "thu": true, "thurs": true, "fri": true, "sat": true, "sun": true,
+	"пн": true, "вт": true, "ср": true, "чт": true, "пт": true, "сб": true, "вс": true,
+}
+
+var quickAddMonths = map[string]time.Month{
+	"january": time.January, "jan": time.January, "января": time.January, "янв": time.January,
+	"february": time.February, "feb": time.February, "февраля": time.February, "фев": time.February,
+	"march": time.March, "mar": time.March, "марта": time.March, "мар": time.March,
+	"april": time.April, "apr": time.April, "апреля": time.April, "апр": time.April,
+	"may": time.May, "мая": time.May,
+	"june": time.June, "jun": time.June, "июня": time.June, "июн": time.June,
+	"july": time.July, "jul": time.July, "июля": time.July, "июл": time.July,
+	"august": time.August, "aug": time.August, "августа": time.August, "авг": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September, "сентября": time.September, "сен": time.September,
+	"october": time.October, "oct": time.October, "октября": time.October, "окт": time.October,
+	"november": time.November, "nov": time.November, "ноября": time.November, "ноя": time.November,
+	"december": time.December, "dec": time.December, "декабря": time.December, "дек": time.December,
+}
+
+var quickAddNumbers = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"один": 1, "одну": 1, "два": 2, "две": 2, "три": 3, "четыре": 4, "пять": 5,
+}
+
+// Слова перед датой или временем, которые не должны остаться в названии
+var quickAddPrepositions = map[string]bool{
+	"on": true, "at": true, "by": true, "due": true, "в": true, "во": true, "к": true, "до": true, "на": true,
+}
+
+var quickAddNext = map[string]bool{
+	"next": true, "следующий": true, "следующую": true, "следующее": true, "следующей": true, "следующем": true,
+}
+
+type quickAddParser struct {
+	words   []string
+	lower   []string
+	used    []bool
+	now     time.Time
+	today   time.Time
+	result  QuickAdd
+	date    *time.Time
+	exact   *time.Time
+	hour    int
+	minute  int
+	hasTime bool
+}
+
+// ParseQuickAdd разбирает строку быстрого добавления. now задаёт текущий момент в часовом поясе пользователя:
+// относительные даты и время считаются в нём. Срок без времени возвращается полночью UTC (AllDay),
+// как хранятся сроки на весь день. Нераспознанные слова остаются в названии.
+//
+// Распознаются:
+//   - приоритет: !high, !medium, !low, !1–!3, !!!, !высокий, !средний, !низкий;
+//   - теги: #tag;
+//   - даты: today, tomorrow, day after tomorrow, сегодня, завтра, послезавтра, in 3 days, через неделю,
+//     friday, next monday, в пятницу, в следующую среду, next week, на следующей неделе, next month,
+//     2026-05-01, 01.05, 1 may, may 1st, 1 мая;
+//     сокращения дней недели (sat, пн) — только после предлога или next либо в конце строки,
+//     01.05 без года — только после предлога или в конце строки;
+//   - время: 5pm, 5:30 pm, 17:00, noon, в 9 утра, в 7 вечера, полдень, а также in 2 hours, через 30 минут.
+func ParseQuickAdd(input string, now time.Time) QuickAdd {
+	words := strings.Fields(input)
+	p := &quickAddParser{
+		words: words,
+		lower: make([]string, len(words)),
+		used:  make([]bool, len(words)),
+		now:   now,
+	}
+	y, m, d := now.Date()
+	p.today = time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	p.result.Tags = []string{}
+	p.result.Matches = []QuickAddMatch{}
+	for i, w := range words {
+		p.lower[i] = strings.TrimRight(strings.ToLower(w), quickAddTrailing)
+	}
+
+	for i := range words {
+		if p.used[i] {
+			continue
+		}
+		if priority, ok := quickAddPriorities[strings.ToLower(strings.TrimRight(words[i], ",.;"))]; ok && p.result.Priority == "" {
+			p.result.Priority = priority
+			p.take(i, 1, "priority")
+			continue
+		}
+		if m := quickAddTag.FindStringSubmatch(strings.TrimRight(words[i], quickAddTrailing)); m != nil {
+			p.result.Tags = append(p.result.Tags, m[1])
+			p.take(i, 1, "tag")
+			continue
+		}
+		if p.date == nil && p.exact == nil {
+			if n := p.matchDate(i); n > 0 {
+				p.take(p.withPreposition(i), i+n-p.withPreposition(i), "date")
+				continue
+			}
+		}
+		if !p.hasTime && p.exact == nil {
+			if n := p.matchTime(i); n > 0 {
+				p.take(p.withPreposition(i), i+n-p.withPreposition(i), "time")
+				continue
+			}
+		}
+	}
+
+	var title []string
+	for i, w := range words {
+		if !p.used[i] {
+			title = append(title, w)
+		}
+	}
+	p.result.Title = strings.TrimSpace(strings.Join(title, " "))
+	p.resolveDue()
+	return p.result
+}
+
+// take помечает n слов начиная с i как распознанные
+func (p *quickAddParser) take(i, n int, kind string) {
+	for j := i; j < i+n; j++ {
+		p.used[j] = true
+	}
+	p.result.Matches = append(p.result.Matches, QuickAddMatch{Kind: kind, Text: strings.Join(p.words[i:i+n], " ")})
+}
+
+// withPreposition сдвигает начало фрагмента на предлог перед ним ("on friday", "в 5 вечера")
+func (p *quickAddParser) withPreposition(i int) int {
+	if i > 0 && !p.used[i-1] && quickAddPrepositions[p.lower[i-1]] {
+		return i - 1
+	}
+	return i
+}
+
+// anchored проверяет, что слово i стоит после предлога или в конце строки, где дальше идут
+// только теги и приоритет. Так неоднозначные слова ("sat", "1.2") не считаются датой посреди названия.
+func (p *quickAddParser) anchored(i int) bool {
+	if p.withPreposition(i) < i {
+		return true
+	}
+	for _, w := range p.words[i+1:] {
+		if _, ok := quickAddPriorities[strings.ToLower(strings.TrimRight(w, ",.;"))]; ok {
+			continue
+		}
+		if !quickAddTag.MatchString(strings.TrimRight(w, quickAddTrailing)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *quickAddParser) word(i int) string {
+	if i < len(p.lower) && !p.used[i] {
+		return p.lower[i]
+	}
+	return ""
+}
+
+func (p *quickAddParser) setDate(t time.Time) {
+	p.date = &t
+}
+
+// matchDate распознаёт дату начиная со слова i и возвращает число занятых слов
+func (p *quickAddParser) matchDate(i int) int {
+	w := p.word(i)
+	switch w {
+	case "today", "сегодня":
+		p.setDate(p.today)
+		return 1
+	case "tonight":
+		p.setDate(p.today)
+		p.hour, p.hasTime = 20, true
+		return 1
+	case "tomorrow", "tmr", "tmrw", "завтра":
+		p.setDate(p.today.AddDate(0, 0, 1))
+		return 1
+	case "послезавтра":
+		p.setDate(p.today.AddDate(0, 0, 2))
+		return 1
+	case "day":
+		if p.word(i+1) == "after" && p.word(i+2) == "tomorrow" {
+			p.setDate(p.today.AddDate(0, 0, 2))
+			return 3
+		}
+	case "in", "через":
+		return p.matchRelative(i)
+	}
+
+	if quickAddNext[w] {
+		next := p.word(i + 1)
+		if weekday, ok := quickAddWeekdays[next]; ok {
+			// "next friday" — пятница следующей недели
+			p.setDate(p.weekStart().AddDate(0, 0, 7+(int(weekday)+6)%7))
+			return 2
+		}
+		switch {
+		case next == "week" || strings.HasPrefix(next, "недел"):
+			p.setDate(p.weekStart().AddDate(0, 0, 7))
+			return 2
+		case next == "month" || strings.HasPrefix(next, "месяц"):
+			p.setDate(time.Date(p.today.Year(), p.today.Month()+1, 1, 0, 0, 0, 0, p.today.Location()))
+			return 2
+		}
+	}
+	if weekday, ok := quickAddWeekdays[w]; ok && (!quickAddWeekdayAbbrevs[w] || p.anchored(i)) {
+		// Ближайший такой день после сегодняшнего
+		days := (int(weekday) - int(p.today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		p.setDate(p.today.AddDate(0, 0, days))
+		return 1
+	}
+
+	if m := quickAddISODate.FindStringSubmatch(w); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		return p.setCalendarDate(year, time.Month(month), day, 1)
+	}
+	// "01.05" без года легко спутать с номером версии ("version 1.2 release")
+	if m := quickAddDotDate.FindStringSubmatch(w); m != nil && (m[3] != "" || p.anchored(i)) {
+		day, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		year := 0
+		if m[3] != "" {
+			year, _ = strconv.Atoi(m[3])
+			if year < 100 {
+				year += 2000
+			}
+		}
+		return p.setCalendarDate(year, time.Month(month), day, 1)
+	}
+	// "may 1st 2027" и "1 мая 2027"
+	if month, ok := quickAddMonths[w]; ok {
+		if m := quickAddDay.FindStringSubmatch(p.word(i + 1)); m != nil {
+			day, _ := strconv.Atoi(m[1])
+			return p.withYear(i+2, month, day, 2)
+		}
+	}
+	if m := quickAddDay.FindStringSubmatch(w); m != nil {
+		if month, ok := quickAddMonths[p.word(i+1)]; ok {
+			day, _ := strconv.Atoi(m[1])
+			return p.withYear(i+2, month, day, 2)
+		}
+	}
+	return 0
+}
+
+// matchRelative распознаёт "in 3 days", "in an hour", "через 2 недели", "через час"
+func (p *quickAddParser) matchRelative(i int) int {
+	n, unit, size := 1, p.word(i+1), 2
+	if count, err := strconv.Atoi(unit); err == nil {
+		n, unit, size = count, p.word(i+2), 3
+	} else if count, ok := quickAddNumbers[unit]; ok {
+		n, unit, size = count, p.word(i+2), 3
+	} else if p.word(i) == "in" {
+		// В английском количество обязательно: "in week" не распознаём
+		return 0
+	}
+	if n <= 0 || n > 1000 {
+		return 0
+	}
+	switch {
+	case unit == "day" || unit == "days" || unit == "день" || unit == "дня" || unit == "дней":
+		p.setDate(p.today.AddDate(0, 0, n))
+	case unit == "week" || unit == "weeks" || strings.HasPrefix(unit, "недел"):
+		p.setDate(p.today.AddDate(0, 0, 7*n))
+	case unit == "month" || unit == "months" || strings.HasPrefix(unit, "месяц"):
+		p.setDate(p.today.AddDate(0, n, 0))
+	case unit == "hour" || unit == "hours" || unit == "hr" || unit == "hrs" || strings.HasPrefix(unit, "час"):
+		exact := p.now.Add(time.Duration(n) * time.Hour)
+		p.exact = &exact
+	case unit == "minute" || unit == "minutes" || unit == "min" || unit == "mins" || strings.HasPrefix(unit, "минут"):
+		exact := p.now.Add(time.Duration(n) * time.Minute)
+		p.exact = &exact
+	default:
+		return 0
+	}
+	return size
+}
+
+// withYear дописывает к дате год, если он идёт следующим словом
+func (p *quickAddParser) withYear(i int, month time.Month, day, size int) int {
+	if w := p.word(i); quickAddYear.MatchString(w) {
+		year, _ := strconv.Atoi(w)
+		return p.setCalendarDate(year, month, day, size+1)
+	}
+	return p.setCalendarDate(0, month, day, size)
+}
+
+// setCalendarDate задаёт явную дату; без года берётся ближайшая такая дата не раньше сегодняшней
+func (p *quickAddParser) setCalendarDate(year int, month time.Month, day, size int) int {
+	explicitYear := year != 0
+	if !explicitYear {
+		year = p.today.Year()
+	}
+	date := time.Date(year, month, day, 0, 0, 0, 0, p.today.Location())
+	if month < time.January || month > time.December || date.Day() != day {
+		return 0
+	}
+	if !explicitYear && date.Before(p.today) {
+		date = date.AddDate(1, 0, 0)
+	}
+	p.setDate(date)
+	return size
+}
+
+// weekStart возвращает понедельник текущей недели
+func (p *quickAddParser) weekStart() time.Time {
+	return p.today.AddDate(0, 0, -((int(p.today.Weekday()) + 6) % 7))
+}
+
+// matchTime распознаёт время начиная со слова i и возвращает число занятых слов
+func (p *quickAddParser) matchTime(i int) int {
+	w := p.word(i)
+	switch w {
+	case "noon", "midday", "полдень":
+		p.hour, p.minute, p.hasTime = 12, 0, true
+		return 1
+	}
+	m := quickAddClock.FindStringSubmatch(w)
+	if m == nil {
+		return 0
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	size := 1
+	suffix := m[3]
+	if suffix == "" {
+		switch next := p.word(i + 1); next {
+		case "am", "pm", "a.m", "p.m":
+			suffix, size = strings.ReplaceAll(next, ".", ""), 2
+		case "утра", "дня", "вечера", "ночи":
+			suffix, size = next, 2
+		}
+	}
+	switch suffix {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0
+		}
+		hour %= 12
+		if suffix == "pm" {
+			hour += 12
+		}
+	case "утра", "ночи":
+		if hour > 12 {
+			return 0
+		}
+		hour %= 12
+	case "дня", "вечера":
+		if hour > 12 {
+			return 0
+		}
+		if hour < 12 {
+			hour += 12
+		}
+	default:
+		// Голое число без минут — скорее количество, чем время
+		if m[2] == "" {
+			return 0
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0
+	}
+	p.hour, p.minute, p.hasTime = hour, minute, true
+	return size
+}
+
+// resolveDue собирает срок из распознанных даты и времени
+func (p *quickAddParser) resolveDue() {
+	loc := p.now.Location()
+	switch {
+	case p.exact != nil:
+		due := *p.exact
+		p.result.DueDate = &due
+	case p.date != nil && p.hasTime:
+		due := time.Date(p.date.Year(), p.date.Month(), p.date.Day(), p.hour, p.minute, 0, 0, loc)
+		p.result.DueDate = &due
+	case p.date != nil:
+		due := time.Date(p.date.Year(), p.date.Month(), p.date.Day(), 0, 0, 0, 0, time.UTC)
+		p.result.DueDate = &due
+		p.result.AllDay = true
+	case p.hasTime:
+		// Только время: сегодня, а если оно уже прошло — завтра
+		due := time.Date(p.today.Year(), p.today.Month(), p.today.Day(), p.hour, p.minute, 0, 0, loc)
+		if !due.After(p.now) {
+			due = due.AddDate(0, 0, 1)
+		}
+		p.result.DueDate = &due
+	}
+}
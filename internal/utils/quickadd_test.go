@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuickAdd(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	// Понедельник, 15:00 по Москве
+	now := time.Date(2026, time.October, 19, 15, 0, 0, 0, msk)
+	day := func(month time.Month, d int) *time.Time {
+		due := time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+		return &due
+	}
+	nextYear := func(month time.Month, d int) *time.Time {
+		due := time.Date(2027, month, d, 0, 0, 0, 0, time.UTC)
+		return &due
+	}
+	at := func(d, hour, minute int) *time.Time {
+		due := time.Date(2026, time.October, d, hour, minute, 0, 0, msk)
+		return &due
+	}
+
+	tests := []struct {
+		input string
+		title string
+		due   *time.Time
+	}{
+		{"Call mom today", "Call mom", day(time.October, 19)},
+		{"Call mom tomorrow", "Call mom", day(time.October, 20)},
+		{"Call mom day after tomorrow", "Call mom", day(time.October, 21)},
+		{"Позвонить маме сегодня", "Позвонить маме", day(time.October, 19)},
+		{"Позвонить маме завтра", "Позвонить маме", day(time.October, 20)},
+		{"Позвонить маме послезавтра", "Позвонить маме", day(time.October, 21)},
+		{"Call mom in 3 days", "Call mom", day(time.October, 22)},
+		{"Позвонить маме через неделю", "Позвонить маме", day(time.October, 26)},
+		{"Call mom friday", "Call mom", day(time.October, 23)},
+		{"Call mom on friday", "Call mom", day(time.October, 23)},
+		{"Call mom next monday", "Call mom", day(time.October, 26)},
+		{"Позвонить маме в пятницу", "Позвонить маме", day(time.October, 23)},
+		{"Позвонить маме в следующую среду", "Позвонить маме", day(time.October, 28)},
+		{"Call mom next week", "Call mom", day(time.October, 26)},
+		{"Позвонить маме на следующей неделе", "Позвонить маме", day(time.October, 26)},
+		{"Call mom next month", "Call mom", day(time.November, 1)},
+		{"Call mom 2026-05-01", "Call mom", day(time.May, 1)},
+		{"Call mom 01.05", "Call mom", nextYear(time.May, 1)},
+		{"Call mom 1 may", "Call mom", nextYear(time.May, 1)},
+		{"Call mom may 1st", "Call mom", nextYear(time.May, 1)},
+		{"Позвонить маме 1 мая", "Позвонить маме", nextYear(time.May, 1)},
+		{"Call mom 5pm", "Call mom", at(19, 17, 0)},
+		{"Call mom 5:30 pm", "Call mom", at(19, 17, 30)},
+		{"Call mom 17:00", "Call mom", at(19, 17, 0)},
+		{"Call mom noon", "Call mom", at(20, 12, 0)},
+		{"Позвонить маме в 9 утра", "Позвонить маме", at(20, 9, 0)},
+		{"Позвонить маме в 7 вечера", "Позвонить маме", at(19, 19, 0)},
+		{"Позвонить маме в полдень", "Позвонить маме", at(20, 12, 0)},
+		{"Call mom in 2 hours", "Call mom", at(19, 17, 0)},
+		{"Позвонить маме через 30 минут", "Позвонить маме", at(19, 15, 30)},
+		{"Deploy billing fix tomorrow 5pm !high #backend", "Deploy billing fix", at(20, 17, 0)},
+		// Сокращения дней недели
+		{"Fix sat solver", "Fix sat solver", nil},
+		{"Review wed design doc", "Review wed design doc", nil},
+		{"Обсудить пн отчёт", "Обсудить пн отчёт", nil},
+		{"Call mom on sat", "Call mom", day(time.October, 24)},
+		{"Call mom next sat", "Call mom", day(time.October, 31)},
+		{"Call mom sat", "Call mom", day(time.October, 24)},
+		{"Call mom sun #family !low", "Call mom", day(time.October, 25)},
+		{"Позвонить маме в пн", "Позвонить маме", day(time.October, 26)},
+		{"Позвонить маме вс", "Позвонить маме", day(time.October, 25)},
+		// Даты через точку
+		{"version 1.2 release", "version 1.2 release", nil},
+		{"Pay rent by 01.05 !high", "Pay rent", nextYear(time.May, 1)},
+		{"Release 1.2.2027 build", "Release build", nextYear(time.February, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ParseQuickAdd(tt.input, now)
+			if got.Title != tt.title {
+				t.Errorf("title = %q, want %q", got.Title, tt.title)
+			}
+			switch {
+			case tt.due == nil && got.DueDate != nil:
+				t.Errorf("due = %v, want none", got.DueDate)
+			case tt.due != nil && got.DueDate == nil:
+				t.Errorf("due = none, want %v", tt.due)
+			case tt.due != nil && !got.DueDate.Equal(*tt.due):
+				t.Errorf("due = %v, want %v", got.DueDate, tt.due)
+			}
+			if tt.due != nil && got.AllDay != (tt.due.Location() == time.UTC) {
+				t.Errorf("all_day = %v", got.AllDay)
+			}
+		})
+	}
+
+	got := ParseQuickAdd("Deploy billing fix tomorrow 5pm !high #backend", now)
+	if got.Priority != "high" || len(got.Tags) != 1 || got.Tags[0] != "backend" {
+		t.Errorf("priority = %q, tags = %v", got.Priority, got.Tags)
+	}
+}
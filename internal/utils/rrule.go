@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RRule — подмножество правила повторения RFC 5545:
+// FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, COUNT, UNTIL
+type RRule struct {
+	Freq     string
+	Interval int
+	ByDay    []RRuleDay
+	Count    int
+	Until    *time.Time
+}
+
+// RRuleDay — день недели из BYDAY; N задаёт номер дня в месяце (1MO, -1FR), 0 — каждый
+type RRuleDay struct {
+	Weekday time.Weekday
+	N       int
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// maxRRulePeriods ограничивает перебор периодов для правил, которые редко дают даты
+const maxRRulePeriods = 10000
+
+// ParseRRule разбирает строку вида "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10" (префикс "RRULE:" допускается)
+func ParseRRule(s string) (*RRule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	rule := &RRule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rrule part: %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+			if rule.Freq != "DAILY" && rule.Freq != "WEEKLY" && rule.Freq != "MONTHLY" {
+				return nil, fmt.Errorf("unsupported FREQ: %s", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL: %s", value)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT: %s", value)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseRRuleTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL: %s", value)
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(value), ",") {
+				if len(day) < 2 {
+					return nil, fmt.Errorf("invalid BYDAY: %s", value)
+				}
+				weekday, ok := rruleWeekdays[day[len(day)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY: %s", value)
+				}
+				n := 0
+				if prefix := day[:len(day)-2]; prefix != "" {
+					var err error
+					if n, err = strconv.Atoi(prefix); err != nil || n == 0 || n < -5 || n > 5 {
+						return nil, fmt.Errorf("invalid BYDAY: %s", value)
+					}
+				}
+				rule.ByDay = append(rule.ByDay, RRuleDay{Weekday: weekday, N: n})
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rrule part: %s", key)
+		}
+	}
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("COUNT and UNTIL cannot be used together")
+	}
+	if rule.Freq != "MONTHLY" {
+		for _, day := range rule.ByDay {
+			if day.N != 0 {
+				return nil, fmt.Errorf("numbered BYDAY is only supported with FREQ=MONTHLY")
+			}
+		}
+	}
+	return rule, nil
+}
+
+func parseRRuleTime(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s", value)
+}
+
+// Occurrences возвращает до max первых дат серии, начинающейся в start (DTSTART).
+// Даты строятся по местному времени loc, поэтому время суток сохраняется при переходе на летнее время.
+func (r *RRule) Occurrences(start time.Time, loc *time.Location, max int) []time.Time {
+	start = start.In(loc)
+	var result []time.Time
+	for period := 0; period < maxRRulePeriods && len(result) < max; period++ {
+		for _, t := range r.periodDates(start, period, loc) {
+			if t.Before(start) {
+				continue
+			}
+			if r.Until != nil && t.After(*r.Until) {
+				return result
+			}
+			if (r.Count > 0 && len(result) >= r.Count) || len(result) >= max {
+				return result
+			}
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// periodDates возвращает отсортированные даты-кандидаты в периоде с номером period
+func (r *RRule) periodDates(start time.Time, period int, loc *time.Location) []time.Time {
+	y, m, d := start.Date()
+	h, min, sec := start.Clock()
+	step := period * r.Interval
+
+	switch r.Freq {
+	case "DAILY":
+		t := time.Date(y, m, d+step, h, min, sec, 0, loc)
+		if !r.matchesDay(t) {
+			return nil
+		}
+		return []time.Time{t}
+
+	case "WEEKLY":
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []RRuleDay{{Weekday: start.Weekday()}}
+		}
+		// Неделя начинается с понедельника (WKST=MO)
+		monday := d - (int(start.Weekday())+6)%7 + step*7
+		var dates []time.Time
+		for _, day := range days {
+			offset := (int(day.Weekday) + 6) % 7
+			dates = append(dates, time.Date(y, m, monday+offset, h, min, sec, 0, loc))
+		}
+		sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+		return dates
+
+	case "MONTHLY":
+		first := time.Date(y, m+time.Month(step), 1, h, min, sec, 0, loc)
+		daysInMonth := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, loc).Day()
+		if len(r.ByDay) == 0 {
+			if d > daysInMonth {
+				return nil
+			}
+			return []time.Time{time.Date(first.Year(), first.Month(), d, h, min, sec, 0, loc)}
+		}
+		var dates []time.Time
+		for _, day := range r.ByDay {
+			var matches []int
+			for dd := 1; dd <= daysInMonth; dd++ {
+				if time.Date(first.Year(), first.Month(), dd, 0, 0, 0, 0, loc).Weekday() == day.Weekday {
+					matches = append(matches, dd)
+				}
+			}
+			switch {
+			case day.N == 0:
+				for _, dd := range matches {
+					dates = append(dates, time.Date(first.Year(), first.Month(), dd, h, min, sec, 0, loc))
+				}
+			case day.N > 0 && day.N <= len(matches):
+				dates = append(dates, time.Date(first.Year(), first.Month(), matches[day.N-1], h, min, sec, 0, loc))
+			case day.N < 0 && -day.N <= len(matches):
+				dates = append(dates, time.Date(first.Year(), first.Month(), matches[len(matches)+day.N], h, min, sec, 0, loc))
+			}
+		}
+		sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+		return dates
+	}
+	return nil
+}
+
+// matchesDay проверяет ограничение BYDAY для ежедневных правил
+func (r *RRule) matchesDay(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Weekday == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	dueOffsetPart    = regexp.MustCompile(`(\d+)([hdw])`)
+	dueOffsetFormat  = regexp.MustCompile(`^[+-]?(\d+[hdw])+$`)
+)
+
+// TemplateVariables возвращает имена переменных {{name}} из строк в порядке первого появления
+func TemplateVariables(texts ...string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, text := range texts {
+		for _, m := range templateVariable.FindAllStringSubmatch(text, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+		}
+	}
+	return names
+}
+
+// RenderTemplate подставляет значения переменных {{name}}; неизвестные переменные остаются как есть
+func RenderTemplate(text string, vars map[string]string) string {
+	return templateVariable.ReplaceAllStringFunc(text, func(s string) string {
+		name := templateVariable.FindStringSubmatch(s)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return s
+	})
+}
+
+// ValidateDueOffset проверяет смещение срока вида "+3d", "-1w", "2d4h"
+func ValidateDueOffset(offset string) error {
+	if !dueOffsetFormat.MatchString(offset) {
+		return fmt.Errorf("invalid due offset %q: expected e.g. +3d, +1w, +4h", offset)
+	}
+	return nil
+}
+
+// ApplyDueOffset сдвигает base на offset. Дни и недели считаются календарными,
+// поэтому смещение не плывёт при переходе на летнее время.
+func ApplyDueOffset(base time.Time, offset string) (time.Time, error) {
+	if err := ValidateDueOffset(offset); err != nil {
+		return base, err
+	}
+	sign := 1
+	if offset[0] == '-' {
+		sign = -1
+	}
+	for _, m := range dueOffsetPart.FindAllStringSubmatch(offset, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return base, fmt.Errorf("invalid due offset %q", offset)
+		}
+		n *= sign
+		switch m[2] {
+		case "h":
+			base = base.Add(time.Duration(n) * time.Hour)
+		case "d":
+			base = base.AddDate(0, 0, n)
+		case "w":
+			base = base.AddDate(0, 0, 7*n)
+		}
+	}
+	return base, nil
+}
@@ -0,0 +1,50 @@
+package webhooks
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// carrierNAT — адреса операторского NAT (RFC 6598), такие же внутренние, как частные сети
+var carrierNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// NewHTTPClient возвращает клиент доставки, который не подключается к внутренним адресам:
+// loopback, частным сетям, link-local (в том числе метаданным облака) и multicast.
+// Адрес проверяется после разрешения имени при каждом подключении, поэтому защиту не обойти
+// ни DNS-записью на внутренний адрес, ни редиректом. allowLocalhost разрешает loopback
+// для локальной разработки и тестовых получателей.
+func NewHTTPClient(timeout time.Duration, allowLocalhost bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("webhook address %s is not an IP", host)
+			}
+			if !allowedIP(ip, allowLocalhost) {
+				return fmt.Errorf("webhook address %s is not allowed", ip)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Через прокси проверялся бы адрес прокси, а не получателя
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+func allowedIP(ip net.IP, allowLocalhost bool) bool {
+	if ip.IsLoopback() {
+		return allowLocalhost
+	}
+	return !(ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || carrierNAT.Contains(ip))
+}
@@ -0,0 +1,214 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"task_manager/internal/events"
+	"task_manager/internal/models"
+	"task_manager/internal/repositories"
+	"task_manager/internal/scheduler"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	deliveryJob = "webhook_delivery"
+	// maxResponseBody — сколько байт ответа получателя сохраняется в журнале доставок
+	maxResponseBody = 2048
+	enqueueTimeout  = 10 * time.Second
+)
+
+// Dispatcher ставит события задач в очередь доставки и отправляет их на webhooks.
+// Доставки хранятся в Mongo и выполняются планировщиком, который повторяет
+// неудачные попытки с экспоненциальной задержкой.
+type Dispatcher struct {
+	webhooks     *mongo.Collection
+	deliveries   *mongo.Collection
+	scheduler    *scheduler.Scheduler
+	client       *http.Client
+	maxAttempts  int
+	disableAfter int
+}
+
+// Register регистрирует обработчик доставок в планировщике.
+// disableAfter — после скольких подряд окончательно неудачных доставок webhook отключается.
+func Register(s *scheduler.Scheduler, webhooks, deliveries *mongo.Collection, client *http.Client, maxAttempts, disableAfter int) *Dispatcher {
+	d := &Dispatcher{
+		webhooks:     webhooks,
+		deliveries:   deliveries,
+		scheduler:    s,
+		client:       client,
+		maxAttempts:  maxAttempts,
+		disableAfter: disableAfter,
+	}
+	s.Handle(deliveryJob, d.deliver)
+	return d
+}
+
+// HandleEvent ставит доставку события на все подписанные webhooks рабочего пространства.
+// Ключи доставки и задачи планировщика строятся из ID события, поэтому повторная
+// обработка того же события другим экземпляром приложения не создаёт дублей.
+func (d *Dispatcher) HandleEvent(e events.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), enqueueTimeout)
+	defer cancel()
+	r := repositories.NewWebhookRepository(d.webhooks, d.deliveries)
+	webhooks, err := r.ActiveWebhooks(e.WorkspaceID, e.Type, ctx)
+	if err != nil {
+		log.Error().Err(err).Str("event_id", e.ID).Msg("failed to load webhooks")
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		log.Error().Err(err).Str("event_id", e.ID).Msg("failed to encode webhook payload")
+		return
+	}
+	for _, webhook := range webhooks {
+		delivery := &models.WebhookDelivery{
+			WebhookID:   webhook.ID,
+			WorkspaceID: webhook.WorkspaceID,
+			Key:         webhook.ID.Hex() + ":" + e.ID,
+			EventID:     e.ID,
+			Event:       e.Type,
+			Payload:     string(payload),
+		}
+		if _, err := d.enqueue(delivery, ctx); err != nil {
+			log.Error().Err(err).Str("webhook_id", webhook.ID.Hex()).Str("event_id", e.ID).Msg("failed to enqueue webhook delivery")
+		}
+	}
+}
+
+// Redeliver ставит повторную отправку того же тела запроса новой доставкой
+func (d *Dispatcher) Redeliver(original *models.WebhookDelivery, ctx context.Context) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{
+		WebhookID:    original.WebhookID,
+		WorkspaceID:  original.WorkspaceID,
+		Key:          "redeliver:" + primitive.NewObjectID().Hex(),
+		EventID:      original.EventID,
+		Event:        original.Event,
+		Payload:      original.Payload,
+		RedeliveryOf: &original.ID,
+	}
+	return d.enqueue(delivery, ctx)
+}
+
+func (d *Dispatcher) enqueue(delivery *models.WebhookDelivery, ctx context.Context) (*models.WebhookDelivery, error) {
+	r := repositories.NewWebhookRepository(d.webhooks, d.deliveries)
+	stored, err := r.CreateDelivery(delivery, ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = d.scheduler.Schedule(ctx, &models.Job{
+		Type:        deliveryJob,
+		Key:         "webhook:" + stored.ID.Hex(),
+		RunAt:       time.Now(),
+		MaxAttempts: d.maxAttempts,
+		Payload:     map[string]interface{}{"delivery_id": stored.ID.Hex()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job *models.Job) error {
+	deliveryHex, _ := job.Payload["delivery_id"].(string)
+	deliveryID, err := primitive.ObjectIDFromHex(deliveryHex)
+	if err != nil {
+		return nil
+	}
+	r := repositories.NewWebhookRepository(d.webhooks, d.deliveries)
+	delivery, err := r.GetDelivery(deliveryID, ctx)
+	if err != nil {
+		// Доставка удалена вместе с webhook
+		return nil
+	}
+	webhook, err := r.GetWebhook(delivery.WebhookID, ctx)
+	if err != nil || !webhook.Active {
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = "webhook is disabled or deleted"
+		return r.UpdateDelivery(delivery, ctx)
+	}
+
+	delivery.Attempts = job.Attempts
+	status, body, sendErr := d.send(ctx, webhook, delivery)
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	if sendErr == nil {
+		now := time.Now()
+		delivery.Status = models.DeliverySucceeded
+		delivery.Error = ""
+		delivery.DeliveredAt = &now
+		if err := r.UpdateDelivery(delivery, ctx); err != nil {
+			return err
+		}
+		return r.RecordSuccess(webhook.ID, ctx)
+	}
+
+	delivery.Error = sendErr.Error()
+	delivery.Status = models.DeliveryPending
+	if job.Attempts >= job.MaxAttempts {
+		delivery.Status = models.DeliveryFailed
+		disabled, err := r.RecordFailure(webhook.ID, d.disableAfter, ctx)
+		if err != nil {
+			log.Error().Err(err).Str("webhook_id", webhook.ID.Hex()).Msg("failed to record webhook failure")
+		}
+		if disabled {
+			log.Warn().Str("webhook_id", webhook.ID.Hex()).Msg("webhook disabled after repeated failures")
+		}
+	}
+	if err := r.UpdateDelivery(delivery, ctx); err != nil {
+		log.Error().Err(err).Str("delivery_id", delivery.ID.Hex()).Msg("failed to update webhook delivery")
+	}
+	return sendErr
+}
+
+// send отправляет тело доставки и возвращает код и начало тела ответа
+func (d *Dispatcher) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "task-manager-webhooks")
+	req.Header.Set("X-Webhook-Id", webhook.ID.Hex())
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.Hex())
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+// Sign возвращает подпись тела запроса: HMAC-SHA256 от "<timestamp>.<body>" с секретом webhook.
+// Получатель проверяет заголовок X-Webhook-Signature, вычисляя её так же.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
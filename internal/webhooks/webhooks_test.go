@@ -0,0 +1,125 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"task_manager/internal/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testDelivery(url string) (*models.Webhook, *models.WebhookDelivery) {
+	webhook := &models.Webhook{ID: primitive.NewObjectID(), URL: url, Secret: "s3cret"}
+	delivery := &models.WebhookDelivery{
+		ID:        primitive.NewObjectID(),
+		WebhookID: webhook.ID,
+		Event:     "task.updated",
+		Payload:   `{"type":"task.updated"}`,
+	}
+	return webhook, delivery
+}
+
+func TestSendSignsBody(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	d := &Dispatcher{client: NewHTTPClient(time.Second, true)}
+	webhook, delivery := testDelivery(server.URL)
+	status, respBody, err := d.send(context.Background(), webhook, delivery)
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if status != http.StatusOK || respBody != "ok" {
+		t.Errorf("response = %d %q", status, respBody)
+	}
+	if string(body) != delivery.Payload {
+		t.Errorf("body = %q, want %q", body, delivery.Payload)
+	}
+	ts, err := strconv.ParseInt(header.Get("X-Webhook-Timestamp"), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp: %v", err)
+	}
+	if got, want := header.Get("X-Webhook-Signature"), Sign(webhook.Secret, ts, body); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if header.Get("X-Webhook-Event") != delivery.Event || header.Get("X-Webhook-Delivery") != delivery.ID.Hex() {
+		t.Errorf("headers = %v", header)
+	}
+}
+
+func TestSendRejectsNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("try later"))
+	}))
+	defer server.Close()
+
+	d := &Dispatcher{client: NewHTTPClient(time.Second, true)}
+	webhook, delivery := testDelivery(server.URL)
+	status, respBody, err := d.send(context.Background(), webhook, delivery)
+	// Ошибка возвращается планировщику, и он повторяет доставку; код и тело попадают в журнал
+	if err == nil {
+		t.Fatal("expected error for 503")
+	}
+	if status != http.StatusServiceUnavailable || respBody != "try later" {
+		t.Errorf("response = %d %q", status, respBody)
+	}
+}
+
+func TestSendBlocksLoopback(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	d := &Dispatcher{client: NewHTTPClient(time.Second, false)}
+	webhook, delivery := testDelivery(server.URL)
+	if _, _, err := d.send(context.Background(), webhook, delivery); err == nil {
+		t.Fatal("expected loopback delivery to be rejected")
+	}
+	if called {
+		t.Error("receiver was called")
+	}
+}
+
+func TestAllowedIP(t *testing.T) {
+	tests := []struct {
+		ip             string
+		allowLocalhost bool
+		want           bool
+	}{
+		{"93.184.216.34", false, true},
+		{"2606:4700::1111", false, true},
+		{"10.1.2.3", false, false},
+		{"172.16.0.1", false, false},
+		{"192.168.1.1", false, false},
+		{"169.254.169.254", false, false},
+		{"100.64.0.1", false, false},
+		{"100.127.255.254", false, false},
+		{"0.0.0.0", false, false},
+		{"fd00::1", false, false},
+		{"127.0.0.1", false, false},
+		{"::1", false, false},
+		{"127.0.0.1", true, true},
+		{"::1", true, true},
+		{"10.1.2.3", true, false},
+	}
+	for _, tt := range tests {
+		if got := allowedIP(net.ParseIP(tt.ip), tt.allowLocalhost); got != tt.want {
+			t.Errorf("allowedIP(%s, %v) = %v, want %v", tt.ip, tt.allowLocalhost, got, tt.want)
+		}
+	}
+}